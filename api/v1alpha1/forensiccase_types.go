@@ -0,0 +1,404 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Condition types reported on a ForensicCase, one per pipeline step
+const (
+	ConditionLogsCaptured       = "LogsCaptured"
+	ConditionUploaded           = "Uploaded"
+	ConditionDependenciesCloned = "DependenciesCloned"
+	ConditionSnapshotsReady     = "SnapshotsReady"
+	ConditionForensicPodRunning = "ForensicPodRunning"
+)
+
+// SourcePodReference identifies the crashed pod a case was captured from
+type SourcePodReference struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+}
+
+// WorkloadReference identifies the top-level workload of a crashed pod, which
+// stays the same across rollouts, and the revision the pod was created from
+type WorkloadReference struct {
+	// Kind is the kind of the workload (e.g. Deployment, StatefulSet, CronJob, Rollout),
+	// or Pod for standalone pods
+	Kind string `json:"kind"`
+
+	// Name is the name of the workload
+	Name string `json:"name"`
+
+	// PodTemplateHash is the hash of the pod template the pod was created from
+	// (pod-template-hash, rollouts-pod-template-hash or controller-revision-hash)
+	// +optional
+	PodTemplateHash string `json:"podTemplateHash,omitempty"`
+
+	// Revision is the workload revision the pod was created from
+	// (e.g. deployment.kubernetes.io/revision of its ReplicaSet)
+	// +optional
+	Revision string `json:"revision,omitempty"`
+}
+
+// SnapshotReference links a PVC of the source pod to the VolumeSnapshot taken of it
+type SnapshotReference struct {
+	PVC      string `json:"pvc"`
+	Snapshot string `json:"snapshot"`
+}
+
+// LogArtifact describes one captured container log
+type LogArtifact struct {
+	// Key is the file name of the log in the log ConfigMap and in the forensic pod
+	Key string `json:"key"`
+
+	// Container is the container the log was read from
+	Container string `json:"container"`
+
+	// Instance is "previous" for the instance that terminated before the last restart,
+	// or "current" for the instance the pod status reports now
+	Instance string `json:"instance"`
+
+	// SHA256 is the hash of the stored log
+	SHA256 string `json:"sha256"`
+
+	// ExportURL is the location of the exported copy, if any
+	// +optional
+	ExportURL string `json:"exportURL,omitempty"`
+}
+
+// StartupFailure describes why a pod never started
+type StartupFailure struct {
+	// Message is the waiting message of the container, or the message of the
+	// PodScheduled condition for unschedulable pods
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// MissingReferences lists the ConfigMaps, Secrets and PVCs the pod needs that
+	// do not exist, and the missing keys of those that do (e.g. configmap/app-config:LOG_LEVEL)
+	// +optional
+	MissingReferences []string `json:"missingReferences,omitempty"`
+
+	// SchedulingMessages lists the distinct messages of the scheduler's FailedScheduling events
+	// +optional
+	SchedulingMessages []string `json:"schedulingMessages,omitempty"`
+
+	// Events lists the events involving the pod, oldest first, as "<type> <reason>: <message>"
+	// +optional
+	Events []string `json:"events,omitempty"`
+}
+
+// NodePressure is a pressure condition of a node
+type NodePressure struct {
+	// Type is MemoryPressure, DiskPressure or PIDPressure
+	Type string `json:"type"`
+
+	// Status is True while the node is under pressure
+	Status string `json:"status"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ContainerUsage is the resource usage of a container reported by the kubelet
+type ContainerUsage struct {
+	Name string `json:"name"`
+
+	// +optional
+	MemoryWorkingSetBytes int64 `json:"memoryWorkingSetBytes,omitempty"`
+
+	// +optional
+	RootfsUsedBytes int64 `json:"rootfsUsedBytes,omitempty"`
+
+	// +optional
+	LogsUsedBytes int64 `json:"logsUsedBytes,omitempty"`
+}
+
+// Eviction describes the eviction of a pod by the kubelet under node pressure
+type Eviction struct {
+	// Message is the eviction message of the kubelet, naming the starved resource
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// NodeConditions are the pressure conditions of the node at capture time
+	// +optional
+	NodeConditions []NodePressure `json:"nodeConditions,omitempty"`
+
+	// NodeMemoryAvailableBytes and NodeFsAvailableBytes are the signals the kubelet evicts on
+	// +optional
+	NodeMemoryAvailableBytes int64 `json:"nodeMemoryAvailableBytes,omitempty"`
+
+	// +optional
+	NodeFsAvailableBytes int64 `json:"nodeFsAvailableBytes,omitempty"`
+
+	// EphemeralStorageUsedBytes is the ephemeral storage used by the pod
+	// +optional
+	EphemeralStorageUsedBytes int64 `json:"ephemeralStorageUsedBytes,omitempty"`
+
+	// Containers is the usage of each container, from the kubelet Summary API
+	// +optional
+	Containers []ContainerUsage `json:"containers,omitempty"`
+
+	// UsageError is why the usage could not be read, e.g. the kubelet no longer reports the pod
+	// +optional
+	UsageError string `json:"usageError,omitempty"`
+}
+
+// Exception is the last exception or panic found in the log of the crashed
+// container instance
+type Exception struct {
+	// Language is the runtime that printed the stack trace: go, java, python, node or dotnet
+	Language string `json:"language"`
+
+	// Type is the exception type (e.g. java.lang.NullPointerException, ValueError),
+	// or the kind of Go panic (panic, runtime error, fatal error)
+	Type string `json:"type"`
+
+	// Message is the exception message as logged, capped at 512 bytes
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Frames are the top frames of the stack trace, closest to the crash first,
+	// without line numbers and addresses
+	// +optional
+	Frames []string `json:"frames,omitempty"`
+
+	// Fingerprint is the SHA-256 of the type and frames. It is part of the crash signature.
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// ProbableCause is the probable cause of a crash, decoded from the exit code,
+// the termination reason and the crash log
+type ProbableCause struct {
+	// Category is the cause, e.g. OOMKilled, SegmentationFault, CommandNotFound,
+	// ApplicationError, or a log finding such as ConnectionRefused or MissingEnvVar
+	Category string `json:"category"`
+
+	// Signal is the signal encoded in the exit code, e.g. SIGKILL for 137
+	// +optional
+	Signal string `json:"signal,omitempty"`
+
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Evidence is the log line the category was found in, if any
+	// +optional
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// RuleMatch is a custom log rule the crash log matched
+type RuleMatch struct {
+	// Rule is the name of the rule
+	Rule string `json:"rule"`
+
+	// Severity is info, warning or critical
+	Severity string `json:"severity"`
+
+	// +optional
+	Category string `json:"category,omitempty"`
+
+	// Remediation tells responders what to do about the failure
+	// +optional
+	Remediation string `json:"remediation,omitempty"`
+
+	// Actions lists the actions the rule took: hold, skip-capture or page
+	// +optional
+	Actions []string `json:"actions,omitempty"`
+
+	// Line is the last log line the rule matched
+	Line string `json:"line"`
+}
+
+// JobContext describes the Job a crashed pod belonged to, at capture time
+type JobContext struct {
+	// Name is the name of the Job
+	Name string `json:"name"`
+
+	// CronJob is the CronJob that created the Job, if any
+	// +optional
+	CronJob string `json:"cronJob,omitempty"`
+
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// +optional
+	Completions *int32 `json:"completions,omitempty"`
+
+	// Succeeded and Failed are the pods of the Job that succeeded and failed so far
+	// +optional
+	Succeeded int32 `json:"succeeded,omitempty"`
+
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+
+	// FailureReason is the reason of the Failed condition of the Job (e.g.
+	// BackoffLimitExceeded, DeadlineExceeded). Empty while the Job still retries.
+	// +optional
+	FailureReason string `json:"failureReason,omitempty"`
+
+	// +optional
+	FailureMessage string `json:"failureMessage,omitempty"`
+}
+
+// ForensicCaseSpec describes the crash that was captured
+type ForensicCaseSpec struct {
+	// SourcePod is the crashed pod this case was captured from
+	SourcePod SourcePodReference `json:"sourcePod"`
+
+	// CrashSignature is the deduplication signature of the crash
+	CrashSignature string `json:"crashSignature"`
+
+	// Workload is the top-level workload of the source pod
+	// +optional
+	Workload *WorkloadReference `json:"workload,omitempty"`
+
+	// Container is the name of the container that crashed
+	// +optional
+	Container string `json:"container,omitempty"`
+
+	// ExitCode is the exit code of the crashed container
+	ExitCode int32 `json:"exitCode"`
+
+	// Reason is the termination reason reported by the kubelet (e.g. Error, OOMKilled),
+	// the restart criterion that caught the crash (CrashLoopBackOff, RestartDelta, FrequentRestarts),
+	// why the pod never started (ImagePullBackOff, ErrImagePull, CreateContainerConfigError, Unschedulable),
+	// or Evicted
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Policy is the name of the ForensicPolicy applied to the capture, if any
+	// +optional
+	Policy string `json:"policy,omitempty"`
+}
+
+// ForensicCaseStatus records the outcome of each capture step
+type ForensicCaseStatus struct {
+	// Conditions holds one entry per pipeline step
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ForensicPod is the name of the forensic clone in the target namespace
+	// +optional
+	ForensicPod string `json:"forensicPod,omitempty"`
+
+	// LogConfigMap is the name of the ConfigMap holding the captured logs
+	// (the first one when the logs are chunked)
+	// +optional
+	LogConfigMap string `json:"logConfigMap,omitempty"`
+
+	// LogStorage is how the logs are stored in the target namespace: configmap
+	// (a single ConfigMap), chunked (split across LogConfigMaps) or pvc (LogPVC)
+	// +optional
+	// +kubebuilder:validation:Enum=configmap;chunked;pvc
+	LogStorage string `json:"logStorage,omitempty"`
+
+	// LogConfigMaps lists every ConfigMap holding a part of the logs when they are chunked
+	// +optional
+	LogConfigMaps []string `json:"logConfigMaps,omitempty"`
+
+	// LogPVC is the PersistentVolumeClaim holding the logs in pvc mode
+	// +optional
+	LogPVC string `json:"logPVC,omitempty"`
+
+	// LogSHA256 is the SHA-256 of the captured crash log
+	// +optional
+	LogSHA256 string `json:"logSHA256,omitempty"`
+
+	// Logs lists every captured log, including the crash log
+	// +optional
+	Logs []LogArtifact `json:"logs,omitempty"`
+
+	// ExportURL is the location of the exported crash log (e.g. s3://bucket/key)
+	// +optional
+	ExportURL string `json:"exportURL,omitempty"`
+
+	// EvidenceBundleURL is the location of the evidence bundle (pod spec, events,
+	// owner workloads and node state at crash time)
+	// +optional
+	EvidenceBundleURL string `json:"evidenceBundleURL,omitempty"`
+
+	// ManifestConfigMap is the ConfigMap holding the signed evidence manifest
+	// +optional
+	ManifestConfigMap string `json:"manifestConfigMap,omitempty"`
+
+	// ManifestURL is the location of the exported signed evidence manifest
+	// +optional
+	ManifestURL string `json:"manifestURL,omitempty"`
+
+	// ClonedResources lists the ConfigMaps and Secrets copied into the target namespace
+	// +optional
+	ClonedResources []string `json:"clonedResources,omitempty"`
+
+	// Snapshots lists the VolumeSnapshots taken of the source pod's PVCs
+	// +optional
+	Snapshots []SnapshotReference `json:"snapshots,omitempty"`
+
+	// CheckpointLocation is the location of the container checkpoint, if any
+	// +optional
+	CheckpointLocation string `json:"checkpointLocation,omitempty"`
+
+	// StartupFailure is set for pods that never started. These cases have no
+	// forensic pod, since there is nothing to run.
+	// +optional
+	StartupFailure *StartupFailure `json:"startupFailure,omitempty"`
+
+	// Eviction is set for pods evicted by the kubelet
+	// +optional
+	Eviction *Eviction `json:"eviction,omitempty"`
+
+	// Job is set for pods of a Job
+	// +optional
+	Job *JobContext `json:"job,omitempty"`
+
+	// Exception is the last exception or panic found in the crash log, if any
+	// +optional
+	Exception *Exception `json:"exception,omitempty"`
+
+	// ProbableCause is the probable cause of the crash
+	// +optional
+	ProbableCause *ProbableCause `json:"probableCause,omitempty"`
+
+	// RuleMatches lists the custom log rules the crash log matched
+	// +optional
+	RuleMatches []RuleMatch `json:"ruleMatches,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=fcase
+//+kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.sourcePod.namespace`
+//+kubebuilder:printcolumn:name="Pod",type=string,JSONPath=`.spec.sourcePod.name`
+//+kubebuilder:printcolumn:name="Container",type=string,JSONPath=`.spec.container`
+//+kubebuilder:printcolumn:name="Exit",type=integer,JSONPath=`.spec.exitCode`
+//+kubebuilder:printcolumn:name="Forensic Pod",type=string,JSONPath=`.status.forensicPod`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ForensicCase is the record of a single crash capture. Unlike the forensic pod,
+// it is not removed by the TTL cleaner, so it doubles as the crash history.
+type ForensicCase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ForensicCaseSpec   `json:"spec,omitempty"`
+	Status ForensicCaseStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ForensicCaseList contains a list of ForensicCase
+type ForensicCaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ForensicCase `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ForensicCase{}, &ForensicCaseList{})
+}
@@ -0,0 +1,79 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ForensicPolicySpec selects pods and overrides the controller's global capture settings for them.
+// Unset fields fall back to the controller flags.
+type ForensicPolicySpec struct {
+	// Namespaces lists the namespaces the policy applies to by name
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// NamespaceSelector selects the namespaces the policy applies to by label.
+	// Ignored when Namespaces is set. If both are empty, the policy applies to all namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// PodSelector selects pods within the matched namespaces. Empty selects all pods.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// ForensicTTL overrides --forensic-ttl
+	// +optional
+	ForensicTTL *metav1.Duration `json:"forensicTTL,omitempty"`
+
+	// MaxLogSizeBytes overrides --max-log-size
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxLogSizeBytes *int64 `json:"maxLogSizeBytes,omitempty"`
+
+	// EnableSecretCloning overrides --enable-secret-cloning. False redacts cloned secrets.
+	// +optional
+	EnableSecretCloning *bool `json:"enableSecretCloning,omitempty"`
+
+	// RateLimitWindow overrides --rate-limit-window
+	// +optional
+	RateLimitWindow *metav1.Duration `json:"rateLimitWindow,omitempty"`
+
+	// EnableSnapshots overrides --enable-snapshots
+	// +optional
+	EnableSnapshots *bool `json:"enableSnapshots,omitempty"`
+
+	// EnableExport controls whether captured artifacts are exported to the storage backend
+	// +optional
+	EnableExport *bool `json:"enableExport,omitempty"`
+
+	// EnableToolkit overrides --enable-toolkit
+	// +optional
+	EnableToolkit *bool `json:"enableToolkit,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster,shortName=fpol
+//+kubebuilder:printcolumn:name="Namespaces",type=string,JSONPath=`.spec.namespaces`
+//+kubebuilder:printcolumn:name="TTL",type=string,JSONPath=`.spec.forensicTTL`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ForensicPolicy overrides capture settings for the pods it selects.
+// When several policies match a pod, the most specific one wins.
+type ForensicPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ForensicPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ForensicPolicyList contains a list of ForensicPolicy
+type ForensicPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ForensicPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ForensicPolicy{}, &ForensicPolicyList{})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/spf13/cobra"
+
+	"kube-forensics-controller/pkg/storage"
+)
+
+// newDecryptCmd decrypts artifacts downloaded from storage with an identity
+// held by the incident-response team. It runs entirely offline.
+func newDecryptCmd() *cobra.Command {
+	var identityFile string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "decrypt [FILE.age...]",
+		Short: "Decrypt exported artifacts with an age or SSH identity",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			identities, err := loadIdentities(identityFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load identity: %v\n", err)
+				os.Exit(1)
+			}
+			if output != "" && len(args) > 1 {
+				fmt.Fprintf(os.Stderr, "--output needs a single file\n")
+				os.Exit(1)
+			}
+
+			for _, src := range args {
+				dst := output
+				if dst == "" {
+					if !strings.HasSuffix(src, storage.EncryptedSuffix) {
+						fmt.Fprintf(os.Stderr, "%s has no %s suffix, pass --output\n", src, storage.EncryptedSuffix)
+						os.Exit(1)
+					}
+					dst = strings.TrimSuffix(src, storage.EncryptedSuffix)
+				}
+				if err := decryptFile(src, dst, identities); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to decrypt %s: %v\n", src, err)
+					os.Exit(1)
+				}
+				if dst != "-" {
+					fmt.Fprintf(os.Stderr, "Decrypted %s -> %s\n", src, dst)
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&identityFile, "identity", "i", "", "age identity file (AGE-SECRET-KEY-1...) or SSH private key")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file, '-' for stdout. Defaults to the input without .age")
+	cmd.MarkFlagRequired("identity")
+	return cmd
+}
+
+func loadIdentities(path string) ([]age.Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return storage.ParseIdentities(data)
+}
+
+func decryptFile(src string, dst string, identities []age.Identity) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return decryptTo(in, dst, identities)
+}
+
+// decryptTo writes the plaintext of r to dst. A partial file is removed on error.
+func decryptTo(r io.Reader, dst string, identities []age.Identity) error {
+	plain, err := storage.Decrypt(r, identities...)
+	if err != nil {
+		return err
+	}
+	if dst == "-" {
+		_, err = io.Copy(os.Stdout, plain)
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, plain); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/spf13/cobra"
+
+	"kube-forensics-controller/pkg/storage"
+)
+
+// newDownloadCmd fetches exported artifacts straight from storage, which
+// still works after the forensic pod has expired.
+func newDownloadCmd() *cobra.Command {
+	var storageConfig storage.Config
+	var capture string
+	var outputDir string
+	var listOnly bool
+	var identityFile string
+	var podPrefix string
+
+	cmd := &cobra.Command{
+		Use:   "download [NAMESPACE/POD]",
+		Short: "Download archived artifacts of a crashed pod from storage",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ns, pod, ok := strings.Cut(args[0], "/")
+			if !ok || ns == "" || pod == "" {
+				fmt.Fprintf(os.Stderr, "Expected NAMESPACE/POD of the crashed pod, got %q\n", args[0])
+				os.Exit(1)
+			}
+			if !storageConfig.Enabled() {
+				fmt.Fprintf(os.Stderr, "No storage configured, pass e.g. --s3-bucket\n")
+				os.Exit(1)
+			}
+
+			ctx := context.Background()
+			provider, err := storage.New(ctx, storageConfig)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to initialize storage: %v\n", err)
+				os.Exit(1)
+			}
+
+			// 1. Group the artifacts of the pod by capture
+			prefix := fmt.Sprintf("%s/%s/", ns, pod)
+			if podPrefix != "" {
+				prefix = strings.TrimSuffix(podPrefix, "/") + "/"
+			}
+			objects, err := provider.List(ctx, prefix)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list %s: %v\n", prefix, err)
+				os.Exit(1)
+			}
+			if len(objects) == 0 {
+				fmt.Fprintf(os.Stderr, "No archived artifacts under %s\n", prefix)
+				os.Exit(1)
+			}
+			captures := groupCaptures(prefix, objects)
+
+			if listOnly {
+				for _, c := range captures {
+					fmt.Printf("%s  %s  %d files\n", c.latest.UTC().Format(time.RFC3339), c.name, len(c.objects))
+				}
+				return
+			}
+
+			// 2. Pick the requested capture, or the latest one
+			selected := captures[len(captures)-1]
+			if capture != "" {
+				found := false
+				for _, c := range captures {
+					if c.name == capture {
+						selected, found = c, true
+					}
+				}
+				if !found {
+					fmt.Fprintf(os.Stderr, "Capture %s not found, see --list\n", capture)
+					os.Exit(1)
+				}
+			}
+
+			// 3. Download, decrypting encrypted artifacts if an identity is given
+			var identities []age.Identity
+			if identityFile != "" {
+				identities, err = loadIdentities(identityFile)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to load identity: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if outputDir == "" {
+				outputDir = pod
+			}
+			for _, o := range selected.objects {
+				dst := filepath.Join(outputDir, filepath.FromSlash(path.Base(o.Key)))
+				if identities != nil && strings.HasSuffix(dst, storage.EncryptedSuffix) {
+					dst = strings.TrimSuffix(dst, storage.EncryptedSuffix)
+				}
+				if err := download(ctx, provider, o.Key, dst, identities); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to download %s: %v\n", o.Key, err)
+					os.Exit(1)
+				}
+				fmt.Printf("  %s (%d bytes)\n", dst, o.Size)
+			}
+			fmt.Printf("Downloaded capture %s of %s/%s to %s\n", selected.name, ns, pod, outputDir)
+		},
+	}
+
+	gofs := flag.NewFlagSet("storage", flag.ContinueOnError)
+	storageConfig.RegisterFlags(gofs)
+	cmd.Flags().AddGoFlagSet(gofs)
+	// Only meaningful for the controller and the collector job
+	for _, name := range []string{"azure-connection-string-secret", "s3-ca-bundle-configmap", "s3-credentials-secret", "storage-pvc", "encryption-recipients", "encryption-recipients-file"} {
+		cmd.Flags().MarkHidden(name)
+	}
+	cmd.Flags().StringVar(&capture, "capture", "", "Capture to download (see --list). Defaults to the latest")
+	cmd.Flags().StringVarP(&outputDir, "output", "o", "", "Directory to download into. Defaults to the pod name")
+	cmd.Flags().BoolVar(&listOnly, "list", false, "List the archived captures instead of downloading")
+	cmd.Flags().StringVar(&podPrefix, "prefix", "", "Storage prefix of the pod's captures, for a custom --storage-key-template. Defaults to NAMESPACE/POD/")
+	cmd.Flags().StringVarP(&identityFile, "identity", "i", "", "Decrypt .age artifacts with this age identity or SSH private key")
+	return cmd
+}
+
+type archivedCapture struct {
+	name    string
+	latest  time.Time
+	objects []storage.ObjectInfo
+}
+
+// groupCaptures groups objects by the path between the pod prefix and the
+// file name (e.g. 2026/01/02/150405), oldest capture first.
+func groupCaptures(prefix string, objects []storage.ObjectInfo) []*archivedCapture {
+	byName := make(map[string]*archivedCapture)
+	var captures []*archivedCapture
+	for _, o := range objects {
+		name := path.Dir(strings.TrimPrefix(o.Key, prefix))
+		c, ok := byName[name]
+		if !ok {
+			c = &archivedCapture{name: name}
+			byName[name] = c
+			captures = append(captures, c)
+		}
+		c.objects = append(c.objects, o)
+		if o.LastModified.After(c.latest) {
+			c.latest = o.LastModified
+		}
+	}
+	sort.Slice(captures, func(i, j int) bool { return captures[i].latest.Before(captures[j].latest) })
+	return captures
+}
+
+func download(ctx context.Context, provider storage.Provider, key string, dst string, identities []age.Identity) error {
+	r, err := provider.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	if identities != nil && strings.HasSuffix(key, storage.EncryptedSuffix) {
+		return decryptTo(r, dst, identities)
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-forensics-controller/pkg/manifest"
+)
+
+// newExportCmd writes a stored log to stdout after checking it against the
+// hash recorded at capture time.
+func newExportCmd() *cobra.Command {
+	var container string
+	var previous bool
+
+	cmd := &cobra.Command{
+		Use:   "export [POD_NAME]",
+		Short: "Export logs with integrity check (SHA256)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			podName := args[0]
+			file := logFileName(podName, container, previous, cmd.Flags().Changed("previous"))
+
+			// 1. Get the recorded hash of this log
+			expectedHash, source, err := expectedLogHash(podName, file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get log hash: %v\n", err)
+				os.Exit(1)
+			}
+
+			// 2. Fetch Logs
+			fetchCmd := exec.Command("kubectl", "exec", "-n", targetNamespace, podName, "--", "cat", "/forensics/original-logs/"+file)
+			logData, err := fetchCmd.Output()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to fetch logs: %v\n", err)
+				os.Exit(1)
+			}
+
+			// 3. Verify Hash
+			actualHash := manifest.Hash(logData)
+			switch {
+			case expectedHash == "":
+				fmt.Fprintf(os.Stderr, "WARNING: no recorded hash for %s, integrity not checked (SHA256 %s)\n", file, actualHash)
+			case actualHash != expectedHash:
+				fmt.Fprintf(os.Stderr, "WARNING: INTEGRITY CHECK FAILED!\nExpected: %s (%s)\nActual:   %s\n", expectedHash, source, actualHash)
+			default:
+				fmt.Fprintf(os.Stderr, "Integrity Check Passed: %s (%s)\n", actualHash, source)
+			}
+
+			// 4. Output to Stdout
+			fmt.Print(string(logData))
+		},
+	}
+	cmd.Flags().StringVarP(&container, "container", "c", "", "Export the logs of this container instead of the crashed one")
+	cmd.Flags().BoolVarP(&previous, "previous", "p", false, "With --container, export the logs of the instance before the last restart")
+	return cmd
+}
+
+// logFileName returns the file of a log in /forensics/original-logs. Without a
+// container it is crash.log. For the crashed container the instance defaults
+// to the one that crashed unless --previous was given explicitly.
+func logFileName(podName string, container string, previous bool, previousSet bool) string {
+	if container == "" {
+		return "crash.log"
+	}
+	instance := "current"
+	if previous {
+		instance = "previous"
+	} else if !previousSet && crashLogKey(podName) == container+".previous.log" {
+		instance = "previous"
+	}
+	return fmt.Sprintf("%s.%s.log", container, instance)
+}
+
+func crashLogKey(podName string) string {
+	out, _ := exec.Command("kubectl", "get", "pod", "-n", targetNamespace, podName, "-o", "jsonpath={.metadata.annotations.forensic\\.io/crash-log-key}").Output()
+	return strings.TrimSpace(string(out))
+}
+
+// expectedLogHash looks up the hash of a log in the evidence manifest, which
+// has one per log. Without a manifest only the crash log can be checked, against
+// the forensic.io/log-sha256 annotation. The signature is not checked here, see verify.
+func expectedLogHash(podName string, file string) (string, string, error) {
+	out, err := exec.Command("kubectl", "get", "pod", "-n", targetNamespace, podName, "-o",
+		"jsonpath={.metadata.annotations.forensic\\.io/manifest}{\"\\n\"}{.metadata.annotations.forensic\\.io/log-sha256}{\"\\n\"}{.metadata.annotations.forensic\\.io/crash-log-key}").Output()
+	if err != nil {
+		return "", "", err
+	}
+	fields := strings.Split(string(out), "\n")
+	for len(fields) < 3 {
+		fields = append(fields, "")
+	}
+	cmName, logHash, crashKey := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1]), strings.TrimSpace(fields[2])
+
+	// crash.log is a copy of the crashed instance's log
+	name := file
+	if file == "crash.log" && crashKey != "" {
+		name = crashKey
+	}
+
+	if cmName != "" {
+		signed, err := exec.Command("kubectl", "get", "configmap", "-n", targetNamespace, cmName, "-o", "jsonpath={.data.manifest\\.json}").Output()
+		if err != nil {
+			return "", "", fmt.Errorf("cannot read manifest %s: %w", cmName, err)
+		}
+		m, err := manifest.Decode(signed)
+		if err != nil {
+			return "", "", err
+		}
+		for _, a := range m.Artifacts {
+			if a.Kind == manifest.KindLog && a.Name == name {
+				return a.SHA256, "manifest " + cmName, nil
+			}
+		}
+	}
+
+	if name == crashKey || file == "crash.log" {
+		return logHash, "annotation forensic.io/log-sha256", nil
+	}
+	return "", "", nil
+}
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"os"
 	"os/exec"
@@ -23,9 +21,15 @@ func main() {
 		
 Examples:
   kubectl forensic list
+  kubectl forensic cases
   kubectl forensic access <pod-name>
   kubectl forensic logs <pod-name>
-  kubectl forensic export <pod-name> > crash.log`,
+  kubectl forensic logs <pod-name> --container envoy
+  kubectl forensic export <pod-name> > crash.log
+  kubectl forensic export <pod-name> -c envoy --previous > envoy.log
+  kubectl forensic verify <pod-name> --public-key signing.pub
+  kubectl forensic download prod/web-7d9f --s3-bucket evidence --list
+  kubectl forensic decrypt checkpoint.tar.age --identity ir-team.key`,
 	}
 
 	rootCmd.PersistentFlags().StringVarP(&targetNamespace, "namespace", "n", "debug-forensics", "Target namespace for forensic pods")
@@ -41,6 +45,17 @@ Examples:
 		},
 	}
 
+	casesCmd := &cobra.Command{
+		Use:   "cases",
+		Short: "List recorded forensic cases (crash history)",
+		Run: func(cmd *cobra.Command, args []string) {
+			c := exec.Command("kubectl", "get", "forensiccases", "-n", targetNamespace)
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+			c.Run()
+		},
+	}
+
 	accessCmd := &cobra.Command{
 		Use:   "access [POD_NAME]",
 		Short: "Exec into a forensic pod using the injected toolkit",
@@ -72,6 +87,8 @@ Examples:
 		},
 	}
 
+	var logsContainer string
+	var logsPrevious bool
 	logsCmd := &cobra.Command{
 		Use:   "logs [POD_NAME]",
 		Short: "View the original crash logs stored in the forensic pod",
@@ -80,52 +97,16 @@ Examples:
 
 			podName := args[0]
 
-			c := exec.Command("kubectl", "exec", "-n", targetNamespace, podName, "--", "cat", "/forensics/original-logs/crash.log")
+			file := logFileName(podName, logsContainer, logsPrevious, cmd.Flags().Changed("previous"))
+
+			c := exec.Command("kubectl", "exec", "-n", targetNamespace, podName, "--", "cat", "/forensics/original-logs/"+file)
 			c.Stdout = os.Stdout
 			c.Stderr = os.Stderr
 			c.Run()
 		},
 	}
-
-	exportCmd := &cobra.Command{
-		Use:   "export [POD_NAME]",
-		Short: "Export logs with integrity check (SHA256)",
-		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			podName := args[0]
-
-			// 1. Get Hash from Annotation
-			hashCmd := exec.Command("kubectl", "get", "pod", "-n", targetNamespace, podName, "-o", "jsonpath={.metadata.annotations.forensic\\.io/log-sha256}")
-			expectedHashBytes, err := hashCmd.Output()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to get log hash: %v\n", err)
-				os.Exit(1)
-			}
-			expectedHash := strings.TrimSpace(string(expectedHashBytes))
-
-			// 2. Fetch Logs
-			fetchCmd := exec.Command("kubectl", "exec", "-n", targetNamespace, podName, "--", "cat", "/forensics/original-logs/crash.log")
-			logData, err := fetchCmd.Output()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to fetch logs: %v\n", err)
-				os.Exit(1)
-			}
-
-			// 3. Verify Hash
-			hasher := sha256.New()
-			hasher.Write(logData)
-			actualHash := hex.EncodeToString(hasher.Sum(nil))
-
-			if expectedHash != "" && actualHash != expectedHash {
-				fmt.Fprintf(os.Stderr, "WARNING: INTEGRITY CHECK FAILED!\nExpected: %s\nActual:   %s\n", expectedHash, actualHash)
-			} else {
-				fmt.Fprintf(os.Stderr, "Integrity Check Passed: %s\n", actualHash)
-			}
-
-			// 4. Output to Stdout
-			fmt.Print(string(logData))
-		},
-	}
+	logsCmd.Flags().StringVarP(&logsContainer, "container", "c", "", "Show the logs of this container instead of the crashed one")
+	logsCmd.Flags().BoolVarP(&logsPrevious, "previous", "p", false, "With --container, show the logs of the instance before the last restart")
 
 	cleanupCmd := &cobra.Command{
 		Use:   "cleanup",
@@ -139,7 +120,7 @@ Examples:
 		},
 	}
 
-	rootCmd.AddCommand(listCmd, accessCmd, logsCmd, exportCmd, cleanupCmd)
+	rootCmd.AddCommand(listCmd, casesCmd, accessCmd, logsCmd, newExportCmd(), newVerifyCmd(), newDownloadCmd(), newDecryptCmd(), cleanupCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kube-forensics-controller/pkg/manifest"
+)
+
+// newVerifyCmd checks the signed evidence manifest of a forensic pod and
+// recomputes the hash of every artifact reachable from the cluster. The key
+// must be trusted from outside the cluster, with --public-key or
+// --key-fingerprint; a check against the in-cluster key alone is reported as
+// unverified, since whoever can rewrite the evidence can rewrite that key.
+func newVerifyCmd() *cobra.Command {
+	var publicKeyFile string
+	var keyFingerprint string
+	var manifestFile string
+
+	cmd := &cobra.Command{
+		Use:   "verify [POD_NAME]",
+		Short: "Verify the signed evidence manifest and every artifact hash",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			podName := args[0]
+
+			// 1. Fetch the signed manifest and the public key stored next to it
+			cmName := ""
+			if manifestFile == "" || publicKeyFile == "" {
+				out, err := exec.Command("kubectl", "get", "pod", "-n", targetNamespace, podName, "-o", "jsonpath={.metadata.annotations.forensic\\.io/manifest}").Output()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to get forensic pod: %v\n", err)
+					os.Exit(1)
+				}
+				cmName = strings.TrimSpace(string(out))
+				if cmName == "" {
+					fmt.Fprintf(os.Stderr, "Forensic pod %s has no signed manifest\n", podName)
+					os.Exit(1)
+				}
+			}
+
+			var signed []byte
+			var err error
+			if manifestFile != "" {
+				signed, err = os.ReadFile(manifestFile)
+			} else {
+				signed, err = exec.Command("kubectl", "get", "configmap", "-n", targetNamespace, cmName, "-o", "jsonpath={.data.manifest\\.json}").Output()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to read manifest: %v\n", err)
+				os.Exit(1)
+			}
+
+			// 2. Check the signature
+			var pubPEM []byte
+			if publicKeyFile != "" {
+				pubPEM, err = os.ReadFile(publicKeyFile)
+			} else {
+				pubPEM, err = exec.Command("kubectl", "get", "configmap", "-n", targetNamespace, cmName, "-o", "jsonpath={.data.public\\.pem}").Output()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to read public key: %v\n", err)
+				os.Exit(1)
+			}
+			pub, err := manifest.ParsePublicKey(pubPEM)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid public key: %v\n", err)
+				os.Exit(1)
+			}
+			if keyFingerprint != "" {
+				pinned := strings.ToLower(strings.ReplaceAll(keyFingerprint, ":", ""))
+				if actual := manifest.Fingerprint(pub); actual != pinned {
+					fmt.Fprintf(os.Stderr, "WARNING: KEY CHECK FAILED: public key fingerprint %s does not match the pinned %s\n", actual, pinned)
+					os.Exit(1)
+				}
+			}
+			trusted := publicKeyFile != "" || keyFingerprint != ""
+
+			m, err := manifest.Verify(signed, pub)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: SIGNATURE CHECK FAILED: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Signature OK (key %s), %s/%s captured %s\n", manifest.KeyID(pub), m.SourcePod.Namespace, m.SourcePod.Name, m.CreatedAt.Format("2006-01-02 15:04:05Z"))
+
+			// 3. Check every artifact we can reach
+			failed := false
+			for _, a := range m.Artifacts {
+				actual, note := artifactHash(podName, a)
+				switch {
+				case a.SHA256 == "":
+					fmt.Printf("  SKIPPED  %-10s %s (not hashable)\n", a.Kind, a.Name)
+				case note != "":
+					fmt.Printf("  SKIPPED  %-10s %s (%s)\n", a.Kind, a.Name, note)
+				case actual != a.SHA256:
+					fmt.Printf("  MISMATCH %-10s %s\n           expected %s\n           actual   %s\n", a.Kind, a.Name, a.SHA256, actual)
+					failed = true
+				default:
+					fmt.Printf("  OK       %-10s %s\n", a.Kind, a.Name)
+				}
+			}
+
+			if failed {
+				fmt.Fprintf(os.Stderr, "WARNING: INTEGRITY CHECK FAILED!\n")
+				os.Exit(1)
+			}
+			if !trusted {
+				fmt.Fprintf(os.Stderr, "UNVERIFIED: the manifest was checked against the public key stored in the cluster (fingerprint %s), which anyone able to rewrite the evidence can replace. Pass --public-key or --key-fingerprint with a key kept outside the cluster.\n", manifest.Fingerprint(pub))
+				os.Exit(2)
+			}
+			fmt.Println("Integrity Check Passed")
+		},
+	}
+	cmd.Flags().StringVar(&publicKeyFile, "public-key", "", "PEM file with the trusted signing public key")
+	cmd.Flags().StringVar(&keyFingerprint, "key-fingerprint", "", "SHA-256 fingerprint of the trusted signing public key, when using the key stored in the cluster")
+	cmd.Flags().StringVar(&manifestFile, "manifest", "", "Read the signed manifest from a file, e.g. downloaded from S3")
+	return cmd
+}
+
+// artifactHash recomputes the hash of an artifact. The note explains why an
+// artifact could not be checked.
+func artifactHash(podName string, a manifest.Artifact) (string, string) {
+	switch a.Kind {
+	case manifest.KindLog:
+		data, err := exec.Command("kubectl", "exec", "-n", targetNamespace, podName, "--", "cat", "/forensics/original-logs/"+a.Name).Output()
+		if err != nil {
+			return "", fmt.Sprintf("cannot read log: %v", err)
+		}
+		return manifest.Hash(data), ""
+
+	case manifest.KindConfigMap, manifest.KindSecret:
+		out, err := exec.Command("kubectl", "get", a.Kind, "-n", targetNamespace, a.Name, "-o", "json").Output()
+		if err != nil {
+			return "", fmt.Sprintf("cannot read %s: %v", a.Kind, err)
+		}
+		var obj struct {
+			Data       map[string]string `json:"data"`
+			BinaryData map[string]string `json:"binaryData"`
+		}
+		if err := json.Unmarshal(out, &obj); err != nil {
+			return "", err.Error()
+		}
+		data := make(map[string][]byte)
+		for k, v := range obj.Data {
+			if a.Kind == manifest.KindSecret {
+				// Secret data is base64 encoded in the API
+				decoded, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					return "", err.Error()
+				}
+				data[k] = decoded
+			} else {
+				data[k] = []byte(v)
+			}
+		}
+		for k, v := range obj.BinaryData {
+			decoded, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return "", err.Error()
+			}
+			data[k] = decoded
+		}
+		return manifest.HashData(data), ""
+	}
+
+	return "", "stored outside the cluster"
+}
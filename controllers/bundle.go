@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"kube-forensics-controller/pkg/bundle"
+)
+
+const (
+	// EvidenceBundleName is the object name of the bundle next to the exported logs
+	EvidenceBundleName = "evidence.tar.gz"
+
+	annotationDeploymentRevision = "deployment.kubernetes.io/revision"
+)
+
+// buildEvidenceBundle snapshots the cluster state around a crashed pod: the pod
+// itself, its events, its owner workloads and the node it ran on. Evidence that
+// cannot be read is listed in the manifest instead of failing the bundle.
+// Reads go straight to the API server so that no informers are started for
+// workloads and nodes.
+func (r *PodReconciler) buildEvidenceBundle(ctx context.Context, pod *corev1.Pod) *bundle.Bundle {
+	b := bundle.New(bundle.PodRef{
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		UID:       string(pod.UID),
+		Node:      pod.Spec.NodeName,
+	})
+
+	// 1. Pod
+	p := pod.DeepCopy()
+	p.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+	p.ManagedFields = nil
+	addYAML(b, "pod.yaml", "Crashed pod as seen by the controller", p)
+
+	// 2. Container statuses, including termination messages
+	addYAML(b, "container-statuses.yaml", "Init, regular and ephemeral container statuses", struct {
+		InitContainerStatuses      []corev1.ContainerStatus `json:"initContainerStatuses,omitempty"`
+		ContainerStatuses          []corev1.ContainerStatus `json:"containerStatuses,omitempty"`
+		EphemeralContainerStatuses []corev1.ContainerStatus `json:"ephemeralContainerStatuses,omitempty"`
+	}{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses})
+
+	// 3. Events involving the pod
+	events, err := r.KubeClient.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.uid", string(pod.UID)).String(),
+	})
+	if err != nil {
+		b.Error(fmt.Sprintf("events: %v", err))
+	} else {
+		events.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "EventList"}
+		for i := range events.Items {
+			events.Items[i].ManagedFields = nil
+		}
+		addYAML(b, "events.yaml", "Events involving the pod", events)
+	}
+
+	// 4. Owner workloads
+	r.addOwners(ctx, b, pod)
+
+	// 5. Node conditions and resources
+	if pod.Spec.NodeName != "" {
+		node, err := r.KubeClient.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+		if err != nil {
+			b.Error(fmt.Sprintf("node %s: %v", pod.Spec.NodeName, err))
+		} else {
+			addYAML(b, "node.yaml", "Conditions and resources of the node the pod ran on", struct {
+				Name          string                 `json:"name"`
+				Labels        map[string]string      `json:"labels,omitempty"`
+				Unschedulable bool                   `json:"unschedulable,omitempty"`
+				Taints        []corev1.Taint         `json:"taints,omitempty"`
+				Conditions    []corev1.NodeCondition `json:"conditions,omitempty"`
+				Capacity      corev1.ResourceList    `json:"capacity,omitempty"`
+				Allocatable   corev1.ResourceList    `json:"allocatable,omitempty"`
+				NodeInfo      corev1.NodeSystemInfo  `json:"nodeInfo"`
+			}{node.Name, node.Labels, node.Spec.Unschedulable, node.Spec.Taints, node.Status.Conditions, node.Status.Capacity, node.Status.Allocatable, node.Status.NodeInfo})
+		}
+	}
+
+	return b
+}
+
+// addOwners stores the spec of each controller owner of the pod, e.g. Pod ->
+// ReplicaSet -> Deployment, along with its revision. Owners of an unknown kind
+// are only named.
+func (r *PodReconciler) addOwners(ctx context.Context, b *bundle.Bundle, pod *corev1.Pod) {
+	owners, err := r.resolveOwners(ctx, pod)
+	if err != nil {
+		b.Error(err.Error())
+	}
+	for _, o := range owners {
+		if o.Object == nil {
+			if err == nil {
+				b.Manifest.Workloads = append(b.Manifest.Workloads, bundle.Workload{Kind: o.Kind, Name: o.Name})
+			}
+			continue
+		}
+		w := bundle.Workload{Kind: o.Kind, Name: o.Name, Revision: o.Revision}
+		w.File = fmt.Sprintf("owners/%s-%s.yaml", strings.ToLower(o.Kind), o.Name)
+		addYAML(b, w.File, fmt.Sprintf("Owner %s %s", o.Kind, o.Name), o.Object)
+		b.Manifest.Workloads = append(b.Manifest.Workloads, w)
+	}
+}
+
+func addYAML(b *bundle.Bundle, name string, description string, obj interface{}) {
+	if err := b.AddYAML(name, description, obj); err != nil {
+		b.Error(fmt.Sprintf("%s: %v", name, err))
+	}
+}
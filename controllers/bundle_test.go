@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kube-forensics-controller/pkg/bundle"
+)
+
+func TestBuildEvidenceBundle(t *testing.T) {
+	isController := true
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name: "web", Namespace: "default", UID: "deploy-uid",
+		Annotations: map[string]string{annotationDeploymentRevision: "7"},
+	}}
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-5d4f", Namespace: "default",
+		Annotations:     map[string]string{annotationDeploymentRevision: "7"},
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "deploy-uid", Controller: &isController}},
+	}}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web-5d4f-abcde", Namespace: "default", UID: "pod-uid",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d4f", Controller: &isController}},
+		},
+		Spec: corev1.PodSpec{NodeName: "node-1"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "app",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "config missing"}},
+		}}},
+	}
+
+	r := &PodReconciler{KubeClient: fake.NewSimpleClientset(deploy, rs, node, pod)}
+	data, err := r.buildEvidenceBundle(context.Background(), pod).Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// Read the archive back
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string][]byte{}
+	var order []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		files[hdr.Name] = content
+		order = append(order, hdr.Name)
+	}
+	if order[0] != bundle.ManifestName {
+		t.Fatalf("first entry is %s, want %s", order[0], bundle.ManifestName)
+	}
+
+	var m bundle.Manifest
+	if err := json.Unmarshal(files[bundle.ManifestName], &m); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if len(m.Errors) != 0 {
+		t.Errorf("unexpected collection errors: %v", m.Errors)
+	}
+	for _, f := range m.Files {
+		hash := sha256.Sum256(files[f.Name])
+		if hex.EncodeToString(hash[:]) != f.SHA256 {
+			t.Errorf("%s: hash mismatch", f.Name)
+		}
+	}
+	for _, name := range []string{"pod.yaml", "container-statuses.yaml", "events.yaml", "node.yaml", "owners/replicaset-web-5d4f.yaml", "owners/deployment-web.yaml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("bundle is missing %s", name)
+		}
+	}
+	if len(m.Workloads) != 2 || m.Workloads[1].Kind != "Deployment" || m.Workloads[1].Revision != "7" {
+		t.Errorf("unexpected workloads: %+v", m.Workloads)
+	}
+	if !bytes.Contains(files["container-statuses.yaml"], []byte("config missing")) {
+		t.Errorf("termination message not captured")
+	}
+}
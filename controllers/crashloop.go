@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Crash reasons of the restart-based criteria
+const (
+	ReasonCrashLoopBackOff = "CrashLoopBackOff"
+	ReasonRestartDelta     = "RestartDelta"
+	ReasonFrequentRestarts = "FrequentRestarts"
+)
+
+// DefaultRestartRateWindow is the window of --restart-rate-threshold
+const DefaultRestartRateWindow = 10 * time.Minute
+
+// ValidateRestartCriteria checks the thresholds of the restart-based crash
+// criteria. A zero threshold disables its criterion.
+func ValidateRestartCriteria(crashLoopMinRestarts, deltaThreshold, rateThreshold int32, rateWindow time.Duration) error {
+	if crashLoopMinRestarts < 0 || deltaThreshold < 0 || rateThreshold < 0 {
+		return fmt.Errorf("restart thresholds must not be negative")
+	}
+	if rateThreshold > 0 && rateWindow <= 0 {
+		return fmt.Errorf("restart rate threshold %d needs a positive window", rateThreshold)
+	}
+	return nil
+}
+
+// restartHistory is what the controller has seen of one container's restarts
+type restartHistory struct {
+	baseline int32       // RestartCount when first seen or last captured
+	count    int32       // RestartCount last seen
+	times    []time.Time // When new restarts were seen, within the rate window
+}
+
+// restartTracker keeps the restart history of containers between reconciles.
+// It lives in memory: after a controller restart, counting starts over.
+type restartTracker struct {
+	mu         sync.Mutex
+	containers map[string]*restartHistory
+}
+
+// observe records the RestartCount of a container and returns the restarts
+// since the baseline and those seen within the window
+func (t *restartTracker) observe(key string, count int32, now time.Time, window time.Duration) (delta int32, recent int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.containers == nil {
+		t.containers = make(map[string]*restartHistory)
+	}
+	h, ok := t.containers[key]
+	if !ok {
+		// Earlier restarts happened at unknown times, they only set the baseline
+		h = &restartHistory{baseline: count, count: count}
+		t.containers[key] = h
+	}
+	if count < h.count {
+		// The pod was recreated under the same UID, e.g. a static pod
+		h.baseline = count
+	}
+	for i := h.count; i < count; i++ {
+		h.times = append(h.times, now)
+	}
+	h.count = count
+
+	kept := h.times[:0]
+	for _, at := range h.times {
+		if now.Sub(at) < window {
+			kept = append(kept, at)
+		}
+	}
+	h.times = kept
+	return h.count - h.baseline, len(h.times)
+}
+
+// reset starts counting again after a capture, so one restart loop is not
+// captured on every reconcile. It is only called once the capture succeeded:
+// a rate-limited or failed capture keeps the counts for the next reconcile.
+func (t *restartTracker) reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if h, ok := t.containers[key]; ok {
+		h.baseline = h.count
+		h.times = nil
+	}
+}
+
+// prune forgets the containers of pods that no longer exist
+func (t *restartTracker) prune(alive map[string]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.containers {
+		uid, _, _ := strings.Cut(key, "/")
+		if !alive[uid] {
+			delete(t.containers, key)
+		}
+	}
+}
+
+// restartKey identifies a container in the restart tracker
+func restartKey(pod *corev1.Pod, container string) string {
+	return string(pod.UID) + "/" + container
+}
+
+// restartCrash applies the restart-based crash criteria to a container, which
+// catch containers that exit 0 in a loop. It returns the crash reason, or ""
+// if none of the enabled criteria is met. The counts are kept until
+// restartsCaptured is called.
+func (r *PodReconciler) restartCrash(pod *corev1.Pod, status corev1.ContainerStatus, now time.Time) string {
+	cfg := r.Config
+	window := cfg.RestartRateWindow
+	if window <= 0 {
+		window = DefaultRestartRateWindow
+	}
+	key := restartKey(pod, status.Name)
+	var delta int32
+	var recent int
+	if cfg.RestartDeltaThreshold > 0 || cfg.RestartRateThreshold > 0 {
+		delta, recent = r.restarts.observe(key, status.RestartCount, now, window)
+	}
+
+	reason := ""
+	switch {
+	case cfg.CrashLoopMinRestarts > 0 && status.State.Waiting != nil &&
+		status.State.Waiting.Reason == ReasonCrashLoopBackOff && status.RestartCount >= cfg.CrashLoopMinRestarts:
+		reason = ReasonCrashLoopBackOff
+	case cfg.RestartDeltaThreshold > 0 && delta >= cfg.RestartDeltaThreshold:
+		reason = ReasonRestartDelta
+	case cfg.RestartRateThreshold > 0 && int32(recent) >= cfg.RestartRateThreshold:
+		reason = ReasonFrequentRestarts
+	}
+	return reason
+}
+
+// restartsCaptured starts counting the restarts of a container again after
+// its crash was captured
+func (r *PodReconciler) restartsCaptured(pod *corev1.Pod, container string) {
+	r.restarts.reset(restartKey(pod, container))
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+)
+
+// cleanExit is a container status after restarts that all exited 0
+func cleanExit(restarts int32, waiting string) corev1.ContainerStatus {
+	status := corev1.ContainerStatus{
+		Name:         "worker",
+		RestartCount: restarts,
+		LastTerminationState: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{Reason: "Completed", ExitCode: 0},
+		},
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}
+	if waiting != "" {
+		status.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waiting}}
+	}
+	return status
+}
+
+func TestRestartCrashCrashLoopBackOff(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid-1"}}
+	now := time.Now()
+
+	r := &PodReconciler{Config: ForensicsConfig{CrashLoopMinRestarts: 3}}
+	if got := r.restartCrash(pod, cleanExit(2, ReasonCrashLoopBackOff), now); got != "" {
+		t.Errorf("2 restarts: got %q, want no crash", got)
+	}
+	if got := r.restartCrash(pod, cleanExit(3, ReasonCrashLoopBackOff), now); got != ReasonCrashLoopBackOff {
+		t.Errorf("3 restarts: got %q, want %s", got, ReasonCrashLoopBackOff)
+	}
+	if got := r.restartCrash(pod, cleanExit(3, ""), now); got != "" {
+		t.Errorf("running again: got %q, want no crash", got)
+	}
+
+	r = &PodReconciler{}
+	if got := r.restartCrash(pod, cleanExit(10, ReasonCrashLoopBackOff), now); got != "" {
+		t.Errorf("disabled: got %q, want no crash", got)
+	}
+}
+
+func TestReconcileCleanExitLoop(t *testing.T) {
+	// A worker that exits 0 and is restarted into CrashLoopBackOff
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-abcde", Namespace: "default", UID: "worker-uid"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "worker", Image: "worker:1"}}},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{cleanExit(5, ReasonCrashLoopBackOff)},
+		},
+	}
+	r, c, _ := newCaptureReconciler(kubefake.NewSimpleClientset(pod), pod)
+	r.Config.CrashLoopMinRestarts = 3
+
+	forensicPods := reconcilePod(t, r, c, pod)
+	if len(forensicPods) != 1 {
+		t.Fatalf("got %d forensic pods, want one for the restart loop", len(forensicPods))
+	}
+	if got := forensicPods[0].Annotations["forensic.io/exit-code"]; got != "0" {
+		t.Errorf("exit code annotation %q, want 0", got)
+	}
+
+	var cases forensicv1alpha1.ForensicCaseList
+	if err := c.List(context.Background(), &cases, client.InNamespace(r.Config.TargetNamespace)); err != nil {
+		t.Fatal(err)
+	}
+	if len(cases.Items) != 1 || cases.Items[0].Spec.Reason != ReasonCrashLoopBackOff || cases.Items[0].Spec.ExitCode != 0 {
+		t.Fatalf("got cases %+v, want one CrashLoopBackOff case with exit code 0", cases.Items)
+	}
+
+	// The next status update of the same loop is rate limited
+	if forensicPods := reconcilePod(t, r, c, pod); len(forensicPods) != 1 {
+		t.Errorf("got %d forensic pods after the second reconcile, want 1", len(forensicPods))
+	}
+}
+
+func TestRestartCrashDelta(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid-1"}}
+	r := &PodReconciler{Config: ForensicsConfig{RestartDeltaThreshold: 3}}
+	now := time.Now()
+
+	// Restarts before the container was first seen only set the baseline
+	for i, want := range []string{"", "", "", ReasonRestartDelta, "", "", ReasonRestartDelta} {
+		restarts := int32(5 + i)
+		got := r.restartCrash(pod, cleanExit(restarts, ""), now.Add(time.Duration(i)*time.Hour))
+		if got != want {
+			t.Errorf("restart count %d: got %q, want %q", restarts, got, want)
+		}
+		if got != "" {
+			r.restartsCaptured(pod, "worker")
+		}
+	}
+
+	// Until the crash is captured, e.g. while rate limited, it stays a crash
+	if got := r.restartCrash(pod, cleanExit(14, ""), now); got != ReasonRestartDelta {
+		t.Fatalf("got %q, want %s", got, ReasonRestartDelta)
+	}
+	if got := r.restartCrash(pod, cleanExit(14, ""), now); got != ReasonRestartDelta {
+		t.Errorf("uncaptured crash: got %q, want %s again", got, ReasonRestartDelta)
+	}
+}
+
+func TestRestartCrashRate(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "uid-1"}}
+	r := &PodReconciler{Config: ForensicsConfig{RestartRateThreshold: 3, RestartRateWindow: 10 * time.Minute}}
+	start := time.Now()
+
+	// One restart every 6 minutes never reaches 3 within 10 minutes
+	for i := int32(0); i < 6; i++ {
+		if got := r.restartCrash(pod, cleanExit(i, ""), start.Add(time.Duration(i)*6*time.Minute)); got != "" {
+			t.Fatalf("slow restart %d: got %q, want no crash", i, got)
+		}
+	}
+
+	// Three restarts in three minutes do
+	fast := start.Add(time.Hour)
+	for i, want := range []string{"", "", ReasonFrequentRestarts} {
+		if got := r.restartCrash(pod, cleanExit(int32(6+i), ""), fast.Add(time.Duration(i)*time.Minute)); got != want {
+			t.Errorf("fast restart %d: got %q, want %q", i, got, want)
+		}
+	}
+
+	// A capture starts the count over
+	r.restartsCaptured(pod, "worker")
+	if got := r.restartCrash(pod, cleanExit(8, ""), fast.Add(3*time.Minute)); got != "" {
+		t.Errorf("after capture: got %q, want no crash", got)
+	}
+}
+
+func TestRestartTrackerPrune(t *testing.T) {
+	var tracker restartTracker
+	now := time.Now()
+	tracker.observe("uid-1/app", 0, now, time.Minute)
+	tracker.observe("uid-2/app", 0, now, time.Minute)
+
+	tracker.prune(map[string]bool{"uid-2": true})
+	if _, ok := tracker.containers["uid-1/app"]; ok {
+		t.Error("history of a deleted pod was kept")
+	}
+	if _, ok := tracker.containers["uid-2/app"]; !ok {
+		t.Error("history of a live pod was dropped")
+	}
+}
+
+func TestValidateRestartCriteria(t *testing.T) {
+	tests := []struct {
+		crashLoop, delta, rate int32
+		window                 time.Duration
+		wantErr                bool
+	}{
+		{1, 0, 0, 10 * time.Minute, false},
+		{0, 5, 3, time.Minute, false},
+		{-1, 0, 0, 0, true},
+		{1, 0, 3, 0, true},
+	}
+	for _, tt := range tests {
+		err := ValidateRestartCriteria(tt.crashLoop, tt.delta, tt.rate, tt.window)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateRestartCriteria(%d, %d, %d, %s) = %v, wantErr %v", tt.crashLoop, tt.delta, tt.rate, tt.window, err, tt.wantErr)
+		}
+	}
+}
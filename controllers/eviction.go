@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+	"kube-forensics-controller/pkg/kubelet"
+)
+
+// ReasonEvicted is the pod status reason, and crash reason, of pods evicted
+// by the kubelet under node pressure
+const ReasonEvicted = "Evicted"
+
+// isEvicted reports whether the kubelet evicted a pod
+func isEvicted(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == ReasonEvicted
+}
+
+// evictedContainer picks the container to capture of an evicted pod: the
+// first one the kubelet reports as terminated, with its exit code. Evicted
+// pods often have none, in which case the first container is captured
+// without an exit code.
+func evictedContainer(pod *corev1.Pod) (string, int32) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil {
+			return status.Name, status.State.Terminated.ExitCode
+		}
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name, 0
+	}
+	return "", 0
+}
+
+// evictionEvidence records why the kubelet evicted a pod: its eviction
+// message, the pressure conditions of the node and the usage the kubelet
+// reports through its Summary API. Evidence that cannot be read is skipped,
+// the reason for missing usage is kept in UsageError.
+func (r *PodReconciler) evictionEvidence(ctx context.Context, pod *corev1.Pod) *forensicv1alpha1.Eviction {
+	logger := log.FromContext(ctx)
+	ev := &forensicv1alpha1.Eviction{Message: pod.Status.Message}
+	if pod.Spec.NodeName == "" {
+		return ev
+	}
+
+	// 1. Node Pressure
+	node, err := r.KubeClient.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(err, "Failed to read the node of the evicted pod", "node", pod.Spec.NodeName)
+	} else {
+		for _, cond := range node.Status.Conditions {
+			switch cond.Type {
+			case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure:
+				ev.NodeConditions = append(ev.NodeConditions, forensicv1alpha1.NodePressure{
+					Type:    string(cond.Type),
+					Status:  string(cond.Status),
+					Message: cond.Message,
+				})
+			}
+		}
+	}
+
+	// 2. Usage, via the node proxy
+	summary, err := kubelet.NewClient(r.KubeClient).GetSummary(ctx, pod.Spec.NodeName)
+	if err != nil {
+		logger.Error(err, "Failed to read the kubelet stats summary", "node", pod.Spec.NodeName)
+		ev.UsageError = err.Error()
+		return ev
+	}
+	if m := summary.Node.Memory; m != nil {
+		ev.NodeMemoryAvailableBytes = statBytes(m.AvailableBytes)
+	}
+	if fs := summary.Node.Fs; fs != nil {
+		ev.NodeFsAvailableBytes = statBytes(fs.AvailableBytes)
+	}
+	stats := summary.Pod(pod)
+	if stats == nil {
+		// The kubelet drops the stats of a pod once its containers are removed
+		ev.UsageError = "the kubelet no longer reports the pod"
+		return ev
+	}
+	if fs := stats.EphemeralStorage; fs != nil {
+		ev.EphemeralStorageUsedBytes = statBytes(fs.UsedBytes)
+	}
+	for _, c := range stats.Containers {
+		usage := forensicv1alpha1.ContainerUsage{Name: c.Name}
+		if c.Memory != nil {
+			usage.MemoryWorkingSetBytes = statBytes(c.Memory.WorkingSetBytes)
+		}
+		if c.Rootfs != nil {
+			usage.RootfsUsedBytes = statBytes(c.Rootfs.UsedBytes)
+		}
+		if c.Logs != nil {
+			usage.LogsUsedBytes = statBytes(c.Logs.UsedBytes)
+		}
+		ev.Containers = append(ev.Containers, usage)
+	}
+	return ev
+}
+
+// statBytes converts an optional kubelet figure, 0 when not reported
+func statBytes(v *uint64) int64 {
+	if v == nil {
+		return 0
+	}
+	return int64(*v)
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+)
+
+// evictedPod was evicted for memory pressure before any container terminated
+func evictedPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "prod", UID: "cache-uid"},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []corev1.Container{{Name: "redis"}, {Name: "exporter"}},
+		},
+		Status: corev1.PodStatus{
+			Phase:   corev1.PodFailed,
+			Reason:  ReasonEvicted,
+			Message: "The node was low on resource: memory. Container redis was using 1950Mi, request is 1Gi.",
+		},
+	}
+}
+
+func TestEvictedContainer(t *testing.T) {
+	pod := evictedPod()
+	if !isEvicted(pod) {
+		t.Fatal("evicted pod not detected")
+	}
+	if name, code := evictedContainer(pod); name != "redis" || code != 0 {
+		t.Errorf("got (%s, %d), want the first container without an exit code", name, code)
+	}
+
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "redis", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		{Name: "exporter", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "ContainerStatusUnknown"}}},
+	}
+	if name, code := evictedContainer(pod); name != "exporter" || code != 137 {
+		t.Errorf("got (%s, %d), want the terminated container and its exit code", name, code)
+	}
+
+	pod.Status.Reason = ""
+	if isEvicted(pod) {
+		t.Error("failed pod without the Evicted reason detected as evicted")
+	}
+}
+
+func TestReconcileFailedPodExitCode(t *testing.T) {
+	// A pod that failed without being evicted has no exit code of its own
+	pod := evictedPod()
+	pod.Status.Reason = "DeadlineExceeded"
+	r, c, _ := newCaptureReconciler(kubefake.NewSimpleClientset(pod), pod)
+
+	forensicPods := reconcilePod(t, r, c, pod)
+	if len(forensicPods) != 1 {
+		t.Fatalf("got %d forensic pods, want 1", len(forensicPods))
+	}
+	if got := forensicPods[0].Annotations["forensic.io/exit-code"]; got != "1" {
+		t.Errorf("exit code annotation %q, want 1", got)
+	}
+}
+
+func TestEvictionEvidence(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/nodes/node-1":
+			io.WriteString(w, `{"kind":"Node","apiVersion":"v1","metadata":{"name":"node-1"},"status":{"conditions":[
+				{"type":"MemoryPressure","status":"True","message":"kubelet has insufficient memory available"},
+				{"type":"DiskPressure","status":"False","message":"kubelet has no disk pressure"},
+				{"type":"Ready","status":"True"}]}}`)
+		case "/api/v1/nodes/node-1/proxy/stats/summary":
+			io.WriteString(w, `{"node":{"memory":{"availableBytes":52428800},"fs":{"availableBytes":1073741824}},"pods":[
+				{"podRef":{"name":"other","namespace":"prod","uid":"other-uid"},"containers":[{"name":"app"}]},
+				{"podRef":{"name":"cache","namespace":"prod","uid":"cache-uid"},
+				 "containers":[{"name":"redis","memory":{"workingSetBytes":2044723200},"rootfs":{"usedBytes":4096},"logs":{"usedBytes":8192}}],
+				 "ephemeral-storage":{"usedBytes":12288}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	kube, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &PodReconciler{KubeClient: kube}
+
+	got := r.evictionEvidence(context.Background(), evictedPod())
+	want := &forensicv1alpha1.Eviction{
+		Message: evictedPod().Status.Message,
+		NodeConditions: []forensicv1alpha1.NodePressure{
+			{Type: "MemoryPressure", Status: "True", Message: "kubelet has insufficient memory available"},
+			{Type: "DiskPressure", Status: "False", Message: "kubelet has no disk pressure"},
+		},
+		NodeMemoryAvailableBytes:  52428800,
+		NodeFsAvailableBytes:      1073741824,
+		EphemeralStorageUsedBytes: 12288,
+		Containers: []forensicv1alpha1.ContainerUsage{
+			{Name: "redis", MemoryWorkingSetBytes: 2044723200, RootfsUsedBytes: 4096, LogsUsedBytes: 8192},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("eviction evidence\n got %+v\nwant %+v", got, want)
+	}
+
+	// The kubelet no longer reports the pod
+	pod := evictedPod()
+	pod.UID = "gone-uid"
+	if got := r.evictionEvidence(context.Background(), pod); got.UsageError == "" || got.Containers != nil {
+		t.Errorf("missing usage not explained: %+v", got)
+	}
+}
@@ -0,0 +1,441 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// EvidenceConfigMap stores all logs in a single ConfigMap that is mounted directly
+	EvidenceConfigMap = "configmap"
+	// EvidenceChunked splits logs across several ConfigMaps that an init container
+	// of the forensic pod reassembles into an emptyDir
+	EvidenceChunked = "chunked"
+	// EvidencePVC writes logs to a PersistentVolumeClaim of their own. A helper
+	// pod reassembles the chunked ConfigMaps onto the claim, which the forensic
+	// pod then mounts read-only.
+	EvidencePVC = "pvc"
+
+	// evidenceConfigMapBudget keeps each ConfigMap below the 1MiB object size limit,
+	// leaving room for metadata
+	evidenceConfigMapBudget = 900 * 1024
+	// evidenceChunkSize is the size of a single log part in chunked mode
+	evidenceChunkSize = 256 * 1024
+	// evidencePVCMinSize is the smallest evidence claim requested
+	evidencePVCMinSize = 1024 * 1024 * 1024
+	// evidenceLoaderTimeout bounds the wait for the loader to write an evidence PVC
+	evidenceLoaderTimeout = 2 * time.Minute
+
+	// helperImage runs the toolkit installer and the log assembler
+	helperImage = "busybox:1.36-musl" // Use musl/static build to ensure compatibility across distros
+
+	logVolName      = "forensic-logs"
+	logChunkVolName = "forensic-log-chunks"
+	logMountPath    = "/forensics/original-logs"
+	// logsCompleteMarker is written by the PVC loader once every log is in place
+	logsCompleteMarker = ".complete"
+
+	// LabelLogLoader marks the helper pods that write evidence PVCs
+	LabelLogLoader = "forensic.io/log-loader"
+)
+
+// EvidenceStore keeps the captured logs of a crash in the target namespace.
+// Objects created before a failure are removed again, so a failed Store
+// leaves nothing behind.
+type EvidenceStore interface {
+	// Store persists the logs, crashed instance first
+	Store(ctx context.Context, pod *corev1.Pod, logs []capturedLog) (*logEvidence, error)
+}
+
+// logEvidence describes where the captured logs of a crash are stored in the
+// target namespace and how the forensic pod gets them back.
+type logEvidence struct {
+	Mode       string
+	ConfigMaps []string
+	PVC        string // PVC mode: claim holding the reassembled logs
+	Loader     string // PVC mode: helper pod writing the claim
+	crashKey   string
+	keys       []string            // Log keys, crashed instance first
+	chunks     map[string][]string // Chunked and PVC mode: log key to its ordered part keys
+}
+
+// describe summarizes where the logs went, for conditions and events
+func (e *logEvidence) describe() string {
+	switch e.Mode {
+	case EvidencePVC:
+		return fmt.Sprintf("PVC %s", e.PVC)
+	case EvidenceChunked:
+		return fmt.Sprintf("%d chunked ConfigMaps", len(e.ConfigMaps))
+	default:
+		return fmt.Sprintf("ConfigMap %s", e.ConfigMaps[0])
+	}
+}
+
+// storeLogEvidence persists the captured logs, choosing the representation by
+// size: a single ConfigMap when everything fits, otherwise chunked ConfigMaps
+// or, with --large-log-evidence=pvc, an evidence PVC.
+func (r *PodReconciler) storeLogEvidence(ctx context.Context, pod *corev1.Pod, logs []capturedLog) (*logEvidence, error) {
+	return r.evidenceStore(logs).Store(ctx, pod, logs)
+}
+
+func (r *PodReconciler) evidenceStore(logs []capturedLog) EvidenceStore {
+	total := 0
+	for _, l := range logs {
+		total += len(l.Key) + len(l.Data)
+	}
+	switch {
+	case total <= evidenceConfigMapBudget:
+		return &configMapEvidenceStore{r: r}
+	case r.Config.LargeLogEvidence == EvidencePVC:
+		return &pvcEvidenceStore{r: r, storageClass: r.Config.EvidenceStorageClass, loaderTimeout: evidenceLoaderTimeout}
+	default:
+		return &chunkedEvidenceStore{r: r}
+	}
+}
+
+// configMapEvidenceStore keeps every log in one ConfigMap
+type configMapEvidenceStore struct {
+	r *PodReconciler
+}
+
+func (s *configMapEvidenceStore) Store(ctx context.Context, pod *corev1.Pod, logs []capturedLog) (*logEvidence, error) {
+	ev := newLogEvidence(EvidenceConfigMap, logs)
+	cm := s.r.newLogConfigMap(pod, ev.crashKey)
+	for _, l := range logs {
+		setLogData(cm, l.Key, []byte(l.Data))
+	}
+	if err := s.r.Create(ctx, cm); err != nil {
+		return nil, err
+	}
+	ev.ConfigMaps = []string{cm.Name}
+	return ev, nil
+}
+
+// chunkedEvidenceStore splits every log into parts and packs the parts into as
+// few ConfigMaps as possible
+type chunkedEvidenceStore struct {
+	r *PodReconciler
+}
+
+func (s *chunkedEvidenceStore) Store(ctx context.Context, pod *corev1.Pod, logs []capturedLog) (*logEvidence, error) {
+	ev := newLogEvidence(EvidenceChunked, logs)
+	ev.chunks = make(map[string][]string)
+	var pending []*corev1.ConfigMap
+	current := s.r.newLogConfigMap(pod, ev.crashKey)
+	size := 0
+	for _, l := range logs {
+		data := []byte(l.Data)
+		for part := 0; part == 0 || len(data) > 0; part++ {
+			n := len(data)
+			if n > evidenceChunkSize {
+				n = evidenceChunkSize
+			}
+			key := fmt.Sprintf("%s.part-%03d", l.Key, part)
+			if size > 0 && size+len(key)+n > evidenceConfigMapBudget {
+				pending = append(pending, current)
+				current = s.r.newLogConfigMap(pod, ev.crashKey)
+				size = 0
+			}
+			current.BinaryData[key] = data[:n]
+			size += len(key) + n
+			ev.chunks[l.Key] = append(ev.chunks[l.Key], key)
+			data = data[n:]
+		}
+	}
+	pending = append(pending, current)
+
+	var created []client.Object
+	for _, cm := range pending {
+		if err := s.r.Create(ctx, cm); err != nil {
+			s.r.deleteCreated(ctx, created)
+			return nil, err
+		}
+		created = append(created, cm)
+		ev.ConfigMaps = append(ev.ConfigMaps, cm.Name)
+	}
+	return ev, nil
+}
+
+// pvcEvidenceStore writes the logs to a claim of their own, so that large logs
+// neither sit in etcd for the lifetime of the forensic pod nor fill its emptyDir.
+// The chunked ConfigMaps only carry the logs to the loader pod.
+//
+// The claim is ReadWriteOnce, so the loader and the forensic pod cannot
+// mount it at the same time from different nodes. Store waits for the loader
+// to finish and deletes it, and the forensic pod is only created afterwards.
+type pvcEvidenceStore struct {
+	r             *PodReconciler
+	storageClass  string        // Empty uses the default StorageClass
+	loaderTimeout time.Duration // How long to wait for the loader to write the claim
+}
+
+func (s *pvcEvidenceStore) Store(ctx context.Context, pod *corev1.Pod, logs []capturedLog) (*logEvidence, error) {
+	ev, err := (&chunkedEvidenceStore{r: s.r}).Store(ctx, pod, logs)
+	if err != nil {
+		return nil, err
+	}
+	ev.Mode = EvidencePVC
+	var created []client.Object
+	for _, name := range ev.ConfigMaps {
+		created = append(created, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: s.r.Config.TargetNamespace}})
+	}
+
+	total := int64(0)
+	for _, l := range logs {
+		total += int64(len(l.Data))
+	}
+	// Room for the reassembled logs and crash.log, a copy of the crashed instance
+	size := 3 * total
+	if size < evidencePVCMinSize {
+		size = evidencePVCMinSize
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-logs-", truncateName(pod.Name, 50)),
+			Namespace:    s.r.Config.TargetNamespace,
+			Labels:       map[string]string{LabelSourcePodUID: string(pod.UID)},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: *resource.NewQuantity(size, resource.BinarySI)},
+			},
+		},
+	}
+	if s.storageClass != "" {
+		pvc.Spec.StorageClassName = &s.storageClass
+	}
+	if err := s.r.Create(ctx, pvc); err != nil {
+		s.r.deleteCreated(ctx, created)
+		return nil, err
+	}
+	created = append(created, pvc)
+	ev.PVC = pvc.Name
+
+	// The loader uses the same chunk volume and script as the assembler of chunked mode
+	vols, assembler := (&logEvidence{Mode: EvidenceChunked, ConfigMaps: ev.ConfigMaps, crashKey: ev.crashKey, keys: ev.keys, chunks: ev.chunks}).volumes()
+	assembler.Name = "load-logs"
+	assembler.Command[2] += fmt.Sprintf("\ntouch %s", shellQuote("/logs/"+logsCompleteMarker))
+	vols[0].VolumeSource = corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+	}
+	loader := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-logs-loader-", truncateName(pod.Name, 40)),
+			Namespace:    s.r.Config.TargetNamespace,
+			// No TTL label: the loader is removed with the other dependencies of its forensic pod
+			Labels: map[string]string{
+				LabelSourcePodUID: string(pod.UID),
+				LabelLogLoader:    "true",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                corev1.RestartPolicyOnFailure,
+			AutomountServiceAccountToken: new(bool),
+			Containers:                   []corev1.Container{*assembler},
+			Volumes:                      vols,
+		},
+	}
+	if err := s.r.Create(ctx, loader); err != nil {
+		s.r.deleteCreated(ctx, created)
+		return nil, err
+	}
+	created = append(created, loader)
+	ev.Loader = loader.Name
+
+	if err := s.waitForLoader(ctx, loader); err != nil {
+		s.r.deleteCreated(ctx, created)
+		return nil, err
+	}
+	// The marker is written, the claim is free for the forensic pod
+	if err := s.r.Delete(ctx, loader); client.IgnoreNotFound(err) != nil {
+		s.r.deleteCreated(ctx, created)
+		return nil, fmt.Errorf("deleting log loader %s: %w", loader.Name, err)
+	}
+	return ev, nil
+}
+
+// waitForLoader waits until the loader pod has written every log and the
+// .complete marker, i.e. until it has succeeded
+func (s *pvcEvidenceStore) waitForLoader(ctx context.Context, loader *corev1.Pod) error {
+	var phase corev1.PodPhase
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, s.loaderTimeout, true, func(ctx context.Context) (bool, error) {
+		var p corev1.Pod
+		if err := s.r.apiReader().Get(ctx, client.ObjectKeyFromObject(loader), &p); err != nil {
+			return false, err
+		}
+		phase = p.Status.Phase
+		return phase == corev1.PodSucceeded || phase == corev1.PodFailed, nil
+	})
+	if err != nil {
+		return fmt.Errorf("log loader %s did not finish (phase %q): %w", loader.Name, phase, err)
+	}
+	if phase == corev1.PodFailed {
+		return fmt.Errorf("log loader %s failed", loader.Name)
+	}
+	return nil
+}
+
+func newLogEvidence(mode string, logs []capturedLog) *logEvidence {
+	ev := &logEvidence{Mode: mode, crashKey: logs[0].Key}
+	for _, l := range logs {
+		ev.keys = append(ev.keys, l.Key)
+	}
+	return ev
+}
+
+// deleteCreated removes the objects of a failed store
+func (r *PodReconciler) deleteCreated(ctx context.Context, objs []client.Object) {
+	for _, obj := range objs {
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			log.FromContext(ctx).Error(err, "Failed to delete partial log evidence", "name", obj.GetName())
+		}
+	}
+}
+
+func (r *PodReconciler) newLogConfigMap(pod *corev1.Pod, crashKey string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-logs-", pod.Name),
+			Namespace:    r.Config.TargetNamespace,
+			Labels: map[string]string{
+				LabelSourcePodUID: string(pod.UID),
+			},
+			Annotations: map[string]string{
+				AnnotationCrashLogKey: crashKey,
+			},
+		},
+		Data:       map[string]string{},
+		BinaryData: map[string][]byte{},
+	}
+}
+
+// setLogData stores a log as text when possible. Logs that are not valid UTF-8
+// go to BinaryData so that they are kept byte for byte and still match their hash.
+func setLogData(cm *corev1.ConfigMap, key string, data []byte) {
+	if utf8.Valid(data) {
+		cm.Data[key] = string(data)
+	} else {
+		cm.BinaryData[key] = data
+	}
+}
+
+// volumes returns the volumes that expose the logs at /forensics/original-logs
+// (as the forensic-logs volume) and, in chunked mode, the init container that
+// reassembles them. In PVC mode the init container waits for the loader pod
+// instead. The crashed instance is also exposed as crash.log.
+func (e *logEvidence) volumes() ([]corev1.Volume, *corev1.Container) {
+	// Nothing was stored: mount an empty directory so the pod still starts
+	if e == nil {
+		return []corev1.Volume{{
+			Name:         logVolName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}}, nil
+	}
+
+	if e.Mode == EvidenceConfigMap {
+		items := []corev1.KeyToPath{{Key: e.crashKey, Path: LogConfigMapKey}}
+		for _, key := range e.keys {
+			items = append(items, corev1.KeyToPath{Key: key, Path: key})
+		}
+		return []corev1.Volume{{
+			Name: logVolName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: e.ConfigMaps[0]},
+					Items:                items,
+				},
+			},
+		}}, nil
+	}
+
+	if e.Mode == EvidencePVC {
+		waiter := &corev1.Container{
+			Name:    "wait-for-logs",
+			Image:   helperImage,
+			Command: []string{"/bin/sh", "-c", fmt.Sprintf("until [ -f %s ]; do sleep 2; done", shellQuote("/logs/"+logsCompleteMarker))},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: logVolName, MountPath: "/logs", ReadOnly: true},
+			},
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("50Mi"),
+				},
+			},
+		}
+		return []corev1.Volume{{
+			Name: logVolName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: e.PVC, ReadOnly: true},
+			},
+		}}, waiter
+	}
+
+	var sources []corev1.VolumeProjection
+	for _, name := range e.ConfigMaps {
+		sources = append(sources, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+		})
+	}
+	vols := []corev1.Volume{
+		{
+			Name:         logVolName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+		{
+			Name:         logChunkVolName,
+			VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: sources}},
+		},
+	}
+
+	assembler := &corev1.Container{
+		Name:    "assemble-logs",
+		Image:   helperImage,
+		Command: []string{"/bin/sh", "-c", e.assembleScript("/chunks", "/logs")},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: logChunkVolName, MountPath: "/chunks", ReadOnly: true},
+			{Name: logVolName, MountPath: "/logs"},
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("50Mi"),
+			},
+		},
+	}
+	return vols, assembler
+}
+
+// assembleScript concatenates the parts of every log in order
+func (e *logEvidence) assembleScript(chunkDir string, logDir string) string {
+	keys := append([]string(nil), e.keys...)
+	sort.Strings(keys)
+
+	var script []string
+	script = append(script, "set -e")
+	for _, key := range keys {
+		var parts []string
+		for _, part := range e.chunks[key] {
+			parts = append(parts, shellQuote(chunkDir+"/"+part))
+		}
+		script = append(script, fmt.Sprintf("cat %s > %s", strings.Join(parts, " "), shellQuote(logDir+"/"+key)))
+	}
+	script = append(script, fmt.Sprintf("cp %s %s", shellQuote(logDir+"/"+e.crashKey), shellQuote(logDir+"/"+LogConfigMapKey)))
+	return strings.Join(script, "\n")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
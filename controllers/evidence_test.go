@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestStoreLogEvidenceChunksLargeLogs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	r := &PodReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Config: ForensicsConfig{TargetNamespace: "debug-forensics"},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid-1"}}
+
+	// Small logs fit in a single ConfigMap
+	small := []capturedLog{{Key: "app.current.log", Data: "panic: boom\n"}}
+	ev, err := r.storeLogEvidence(context.Background(), pod, small)
+	if err != nil {
+		t.Fatalf("store small: %v", err)
+	}
+	if ev.Mode != EvidenceConfigMap || len(ev.ConfigMaps) != 1 {
+		t.Fatalf("small logs: mode %s with %d ConfigMaps", ev.Mode, len(ev.ConfigMaps))
+	}
+	if _, assembler := ev.volumes(); assembler != nil {
+		t.Errorf("single ConfigMap should not need an assembler")
+	}
+
+	// Large logs are split across ConfigMaps and put back together in order
+	big := strings.Repeat("0123456789abcdef", 2*1024*1024/16)
+	logs := []capturedLog{
+		{Key: "app.previous.log", Data: big},
+		{Key: "envoy.current.log", Data: "upstream reset\n"},
+	}
+	ev, err = r.storeLogEvidence(context.Background(), pod, logs)
+	if err != nil {
+		t.Fatalf("store large: %v", err)
+	}
+	if ev.Mode != EvidenceChunked || len(ev.ConfigMaps) < 3 {
+		t.Fatalf("large logs: mode %s with %d ConfigMaps", ev.Mode, len(ev.ConfigMaps))
+	}
+
+	parts := map[string][]byte{}
+	for _, name := range ev.ConfigMaps {
+		var cm corev1.ConfigMap
+		if err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "debug-forensics"}, &cm); err != nil {
+			t.Fatalf("get %s: %v", name, err)
+		}
+		size := 0
+		for k, v := range cm.BinaryData {
+			parts[k] = v
+			size += len(k) + len(v)
+		}
+		if size > evidenceConfigMapBudget {
+			t.Errorf("ConfigMap %s holds %d bytes, over the %d budget", name, size, evidenceConfigMapBudget)
+		}
+	}
+	for _, l := range logs {
+		var joined bytes.Buffer
+		for _, key := range ev.chunks[l.Key] {
+			joined.Write(parts[key])
+		}
+		if joined.String() != l.Data {
+			t.Errorf("%s: reassembled %d bytes, want %d", l.Key, joined.Len(), len(l.Data))
+		}
+	}
+
+	vols, assembler := ev.volumes()
+	if assembler == nil || len(vols) != 2 {
+		t.Fatalf("chunked logs need an assembler and two volumes, got %d volumes", len(vols))
+	}
+	if !strings.Contains(assembler.Command[2], "'/logs/crash.log'") {
+		t.Errorf("assembler does not create crash.log:\n%s", assembler.Command[2])
+	}
+}
+
+func TestStoreLogEvidenceOnPVC(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	// The loader finishes as soon as it is created
+	var loader corev1.Pod
+	c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			err := c.Create(ctx, obj, opts...)
+			if p, ok := obj.(*corev1.Pod); ok {
+				p.DeepCopyInto(&loader)
+			}
+			return err
+		},
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			err := c.Get(ctx, key, obj, opts...)
+			if p, ok := obj.(*corev1.Pod); ok && err == nil {
+				p.Status.Phase = corev1.PodSucceeded
+			}
+			return err
+		},
+	}).Build()
+	r := &PodReconciler{
+		Client: c,
+		Config: ForensicsConfig{TargetNamespace: "debug-forensics", LargeLogEvidence: EvidencePVC, EvidenceStorageClass: "fast"},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid-1"}}
+	big := strings.Repeat("x", 2*1024*1024)
+	ev, err := r.storeLogEvidence(context.Background(), pod, []capturedLog{{Key: "app.previous.log", Data: big}})
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if ev.Mode != EvidencePVC || ev.PVC == "" || ev.Loader == "" {
+		t.Fatalf("got mode %s, PVC %q, loader %q", ev.Mode, ev.PVC, ev.Loader)
+	}
+	if got := ev.describe(); got != "PVC "+ev.PVC {
+		t.Errorf("describe() = %q", got)
+	}
+
+	var pvc corev1.PersistentVolumeClaim
+	if err := r.Get(context.Background(), types.NamespacedName{Name: ev.PVC, Namespace: "debug-forensics"}, &pvc); err != nil {
+		t.Fatalf("get PVC: %v", err)
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != "fast" {
+		t.Errorf("PVC storage class = %v, want fast", pvc.Spec.StorageClassName)
+	}
+	// The loader is gone before the forensic pod mounts the ReadWriteOnce claim
+	var pods corev1.PodList
+	if err := r.List(context.Background(), &pods); err != nil || len(pods.Items) != 0 {
+		t.Errorf("the finished loader was not deleted: %d pods, err %v", len(pods.Items), err)
+	}
+	if loader.Name != ev.Loader {
+		t.Fatalf("loader %q was not created", ev.Loader)
+	}
+	if loader.Labels[LabelForensicTTL] != "" {
+		t.Error("the loader must not be picked up by the TTL cleaner on its own")
+	}
+	script := loader.Spec.Containers[0].Command[2]
+	if !strings.Contains(script, "'/logs/crash.log'") || !strings.HasSuffix(script, "touch '/logs/.complete'") {
+		t.Errorf("loader script does not write crash.log and the marker:\n%s", script)
+	}
+
+	vols, waiter := ev.volumes()
+	if len(vols) != 1 || vols[0].PersistentVolumeClaim == nil || vols[0].PersistentVolumeClaim.ClaimName != ev.PVC {
+		t.Fatalf("forensic pod should mount the evidence PVC, got %+v", vols)
+	}
+	if waiter == nil || !strings.Contains(waiter.Command[2], ".complete") {
+		t.Errorf("forensic pod should wait for the loader")
+	}
+}
+
+func TestStoreLogEvidenceOnPVCLoaderTimeout(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &PodReconciler{Client: c, Config: ForensicsConfig{TargetNamespace: "debug-forensics"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid-1"}}
+
+	// The loader never runs, e.g. the claim cannot be provisioned
+	store := &pvcEvidenceStore{r: r, loaderTimeout: 100 * time.Millisecond}
+	if _, err := store.Store(context.Background(), pod, []capturedLog{{Key: "app.previous.log", Data: strings.Repeat("x", 2*1024*1024)}}); err == nil {
+		t.Fatal("expected the loader timeout to be returned")
+	}
+
+	var pods corev1.PodList
+	var pvcs corev1.PersistentVolumeClaimList
+	var cms corev1.ConfigMapList
+	_ = c.List(context.Background(), &pods)
+	_ = c.List(context.Background(), &pvcs)
+	_ = c.List(context.Background(), &cms)
+	if len(pods.Items)+len(pvcs.Items)+len(cms.Items) != 0 {
+		t.Errorf("left behind %d pods, %d PVCs and %d ConfigMaps", len(pods.Items), len(pvcs.Items), len(cms.Items))
+	}
+}
+
+func TestStoreLogEvidenceRemovesPartialChunks(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	creates := 0
+	c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if creates++; creates == 3 {
+				return errors.New("etcd is full")
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	}).Build()
+	r := &PodReconciler{Client: c, Config: ForensicsConfig{TargetNamespace: "debug-forensics"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid-1"}}
+
+	big := strings.Repeat("0123456789abcdef", 4*1024*1024/16)
+	if _, err := r.storeLogEvidence(context.Background(), pod, []capturedLog{{Key: "app.previous.log", Data: big}}); err == nil {
+		t.Fatal("expected the failed create to be returned")
+	}
+
+	var cms corev1.ConfigMapList
+	if err := c.List(context.Background(), &cms); err != nil {
+		t.Fatal(err)
+	}
+	if len(cms.Items) != 0 {
+		t.Errorf("%d ConfigMaps left behind after a failed store", len(cms.Items))
+	}
+}
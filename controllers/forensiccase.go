@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+)
+
+// createForensicCase records a new capture. Failures are returned to the caller,
+// which continues without a case so that a missing CRD never blocks a capture.
+// The name is derived from the crash, so a capture that is retried after a
+// failed step picks up the case of its first attempt instead of adding another.
+func (r *PodReconciler) createForensicCase(ctx context.Context, pod *corev1.Pod, signature string, workload workloadIdentity, crashedContainerName string, exitCode int32, reason string, policyName string) (*forensicv1alpha1.ForensicCase, error) {
+	fc := &forensicv1alpha1.ForensicCase{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      forensicCaseName(pod, crashedContainerName),
+			Namespace: r.Config.TargetNamespace,
+			Labels: map[string]string{
+				LabelSourcePodUID:   string(pod.UID),
+				LabelCrashSignature: signature,
+				LabelWorkload:       workload.Label(),
+			},
+		},
+		Spec: forensicv1alpha1.ForensicCaseSpec{
+			SourcePod: forensicv1alpha1.SourcePodReference{
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				UID:       pod.UID,
+			},
+			CrashSignature: signature,
+			Workload:       workload.Reference(),
+			Container:      crashedContainerName,
+			ExitCode:       exitCode,
+			Reason:         reason,
+			Policy:         policyName,
+		},
+	}
+
+	err := r.Create(ctx, fc)
+	if errors.IsAlreadyExists(err) {
+		err = r.Get(ctx, client.ObjectKeyFromObject(fc), fc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+// forensicCaseName identifies one crash of a container: the source pod UID,
+// the container and its restart count. The next crash of the same container
+// has a higher restart count and gets a case of its own.
+func forensicCaseName(pod *corev1.Pod, container string) string {
+	var restarts int32
+	for _, s := range append(pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses...) {
+		if s.Name == container {
+			restarts = s.RestartCount
+			break
+		}
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%d", pod.UID, container, restarts)))
+	return fmt.Sprintf("%s-%s", truncateName(pod.Name, 50), hex.EncodeToString(hash[:])[:10])
+}
+
+// setCaseCondition sets a pipeline condition on the in-memory case. The
+// status is written once by saveCaseStatus when the capture ends.
+// It is a no-op when no case was created for this capture.
+func (r *PodReconciler) setCaseCondition(fc *forensicv1alpha1.ForensicCase, condType string, status metav1.ConditionStatus, reason string, message string) {
+	if fc == nil {
+		return
+	}
+
+	meta.SetStatusCondition(&fc.Status.Conditions, metav1.Condition{
+		Type:               condType,
+		Status:             status,
+		ObservedGeneration: fc.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// saveCaseStatus persists the status of a case. On a conflict, usually with
+// syncForensicCase reporting the forensic pod phase, the status is reapplied
+// to the latest version, keeping a ForensicPodRunning condition that was set
+// after ours.
+func (r *PodReconciler) saveCaseStatus(ctx context.Context, fc *forensicv1alpha1.ForensicCase) {
+	if fc == nil {
+		return
+	}
+
+	status := fc.Status.DeepCopy()
+	first := true
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !first {
+			var latest forensicv1alpha1.ForensicCase
+			if err := r.Get(ctx, client.ObjectKeyFromObject(fc), &latest); err != nil {
+				return err
+			}
+			status.DeepCopyInto(&fc.Status)
+			fc.ResourceVersion = latest.ResourceVersion
+			synced := meta.FindStatusCondition(latest.Status.Conditions, forensicv1alpha1.ConditionForensicPodRunning)
+			ours := meta.FindStatusCondition(fc.Status.Conditions, forensicv1alpha1.ConditionForensicPodRunning)
+			if synced != nil && (ours == nil || synced.LastTransitionTime.After(ours.LastTransitionTime.Time)) {
+				meta.SetStatusCondition(&fc.Status.Conditions, *synced)
+			}
+		}
+		first = false
+		return r.Status().Update(ctx, fc)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update forensic case status", "case", fc.Name)
+	}
+}
+
+// syncForensicCase mirrors the phase of a forensic pod onto the
+// ForensicPodRunning condition of the case it belongs to, and its hold onto
+// the legal hold of its artifacts.
+func (r *PodReconciler) syncForensicCase(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pod corev1.Pod
+	if err := r.Get(ctx, req.NamespacedName, &pod); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !pod.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	r.syncLegalHold(ctx, &pod)
+
+	caseName := pod.Labels[LabelForensicCase]
+	if caseName == "" {
+		return ctrl.Result{}, nil
+	}
+
+	var fc forensicv1alpha1.ForensicCase
+	if err := r.Get(ctx, types.NamespacedName{Name: caseName, Namespace: pod.Namespace}, &fc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	cond := metav1.Condition{
+		Type:               forensicv1alpha1.ConditionForensicPodRunning,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: fc.Generation,
+		Reason:             string(pod.Status.Phase),
+		Message:            fmt.Sprintf("Forensic pod %s is %s", pod.Name, pod.Status.Phase),
+	}
+	if pod.Status.Phase == corev1.PodRunning {
+		cond.Status = metav1.ConditionTrue
+	}
+	if cond.Reason == "" {
+		cond.Reason = "Unknown"
+	}
+
+	if !meta.SetStatusCondition(&fc.Status.Conditions, cond) {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, r.Status().Update(ctx, &fc)
+}
+
+// markCaseExpired flags the case of a forensic pod removed by the TTL cleaner.
+// The case itself is kept as crash history.
+func (r *PodReconciler) markCaseExpired(ctx context.Context, pod *corev1.Pod) {
+	caseName := pod.Labels[LabelForensicCase]
+	if caseName == "" {
+		return
+	}
+
+	var fc forensicv1alpha1.ForensicCase
+	if err := r.Get(ctx, types.NamespacedName{Name: caseName, Namespace: pod.Namespace}, &fc); err != nil {
+		return
+	}
+	r.setCaseCondition(&fc, forensicv1alpha1.ConditionForensicPodRunning, metav1.ConditionFalse, "Expired", fmt.Sprintf("Forensic pod %s was deleted after its TTL expired", pod.Name))
+	r.saveCaseStatus(ctx, &fc)
+}
+
+// truncateName shortens a name so that generated suffixes still fit the 63 char limit
+func truncateName(name string, max int) string {
+	if len(name) > max {
+		return name[:max]
+	}
+	return name
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+)
+
+func TestSyncForensicCaseMirrorsPodPhase(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = forensicv1alpha1.AddToScheme(scheme)
+
+	fc := &forensicv1alpha1.ForensicCase{
+		ObjectMeta: metav1.ObjectMeta{Name: "crash-app-abcde", Namespace: "debug-forensics"},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "crash-app-forensic-xyz",
+			Namespace: "debug-forensics",
+			Labels:    map[string]string{LabelForensicCase: fc.Name},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(fc, pod).
+		WithStatusSubresource(&forensicv1alpha1.ForensicCase{}).
+		Build()
+	r := &PodReconciler{Client: c, Scheme: scheme, Config: ForensicsConfig{TargetNamespace: "debug-forensics"}}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+
+	var got forensicv1alpha1.ForensicCase
+	if err := c.Get(context.Background(), types.NamespacedName{Name: fc.Name, Namespace: fc.Namespace}, &got); err != nil {
+		t.Fatalf("Failed to get case: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, forensicv1alpha1.ConditionForensicPodRunning) {
+		t.Errorf("Expected %s condition to be True, got %+v", forensicv1alpha1.ConditionForensicPodRunning, got.Status.Conditions)
+	}
+}
+
+func TestCreateForensicCaseIsIdempotent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = forensicv1alpha1.AddToScheme(scheme)
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&forensicv1alpha1.ForensicCase{}).
+		Build()
+	r := &PodReconciler{Client: c, Scheme: scheme, Config: ForensicsConfig{TargetNamespace: "debug-forensics"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "crash-app", Namespace: "default", UID: "uid-1"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "app", RestartCount: 3},
+		}},
+	}
+
+	ctx := context.Background()
+	first, err := r.createForensicCase(ctx, pod, "sig", workloadIdentity{Kind: "Pod", Name: pod.Name}, "app", 1, "Error", "")
+	if err != nil {
+		t.Fatalf("createForensicCase: %v", err)
+	}
+	r.setCaseCondition(first, forensicv1alpha1.ConditionUploaded, metav1.ConditionTrue, "Uploaded", "done")
+	r.saveCaseStatus(ctx, first)
+
+	// A retried capture of the same crash gets the same case back
+	retried, err := r.createForensicCase(ctx, pod, "sig", workloadIdentity{Kind: "Pod", Name: pod.Name}, "app", 1, "Error", "")
+	if err != nil {
+		t.Fatalf("createForensicCase (retry): %v", err)
+	}
+	if retried.Name != first.Name {
+		t.Errorf("retry created case %s, want %s", retried.Name, first.Name)
+	}
+	if !meta.IsStatusConditionTrue(retried.Status.Conditions, forensicv1alpha1.ConditionUploaded) {
+		t.Errorf("retry lost the status of the first attempt: %+v", retried.Status.Conditions)
+	}
+
+	// The next crash of the container is a new case
+	pod.Status.ContainerStatuses[0].RestartCount = 4
+	next, err := r.createForensicCase(ctx, pod, "sig", workloadIdentity{Kind: "Pod", Name: pod.Name}, "app", 1, "Error", "")
+	if err != nil {
+		t.Fatalf("createForensicCase (next crash): %v", err)
+	}
+	if next.Name == first.Name {
+		t.Errorf("next crash reused case %s", first.Name)
+	}
+
+	var cases forensicv1alpha1.ForensicCaseList
+	if err := c.List(ctx, &cases); err != nil {
+		t.Fatal(err)
+	}
+	if len(cases.Items) != 2 {
+		t.Errorf("got %d cases, want 2", len(cases.Items))
+	}
+}
+
+func TestSaveCaseStatusRetriesOnConflict(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = forensicv1alpha1.AddToScheme(scheme)
+
+	fc := &forensicv1alpha1.ForensicCase{
+		ObjectMeta: metav1.ObjectMeta{Name: "crash-app-abcde", Namespace: "debug-forensics"},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(fc).
+		WithStatusSubresource(&forensicv1alpha1.ForensicCase{}).
+		Build()
+	r := &PodReconciler{Client: c, Scheme: scheme, Config: ForensicsConfig{TargetNamespace: "debug-forensics"}}
+
+	ctx := context.Background()
+	var stale forensicv1alpha1.ForensicCase
+	if err := c.Get(ctx, types.NamespacedName{Name: fc.Name, Namespace: fc.Namespace}, &stale); err != nil {
+		t.Fatal(err)
+	}
+
+	// The forensic pod phase is reported in between
+	var synced forensicv1alpha1.ForensicCase
+	if err := c.Get(ctx, types.NamespacedName{Name: fc.Name, Namespace: fc.Namespace}, &synced); err != nil {
+		t.Fatal(err)
+	}
+	r.setCaseCondition(&synced, forensicv1alpha1.ConditionForensicPodRunning, metav1.ConditionTrue, "Running", "running")
+	if err := c.Status().Update(ctx, &synced); err != nil {
+		t.Fatal(err)
+	}
+
+	stale.Status.ForensicPod = "crash-app-forensic-xyz"
+	r.setCaseCondition(&stale, forensicv1alpha1.ConditionLogsCaptured, metav1.ConditionTrue, "Captured", "captured")
+	r.saveCaseStatus(ctx, &stale)
+
+	var got forensicv1alpha1.ForensicCase
+	if err := c.Get(ctx, types.NamespacedName{Name: fc.Name, Namespace: fc.Namespace}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.ForensicPod != "crash-app-forensic-xyz" || !meta.IsStatusConditionTrue(got.Status.Conditions, forensicv1alpha1.ConditionLogsCaptured) {
+		t.Errorf("capture status was not saved: %+v", got.Status)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, forensicv1alpha1.ConditionForensicPodRunning) {
+		t.Errorf("synced pod phase was overwritten: %+v", got.Status.Conditions)
+	}
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+)
+
+// jobContext records the settings and progress of the Job of a crashed pod
+func jobContext(job *batchv1.Job) *forensicv1alpha1.JobContext {
+	jc := &forensicv1alpha1.JobContext{
+		Name:                  job.Name,
+		BackoffLimit:          job.Spec.BackoffLimit,
+		ActiveDeadlineSeconds: job.Spec.ActiveDeadlineSeconds,
+		Completions:           job.Spec.Completions,
+		Succeeded:             job.Status.Succeeded,
+		Failed:                job.Status.Failed,
+	}
+	if owner := metav1.GetControllerOfNoCopy(job); owner != nil && owner.Kind == "CronJob" {
+		jc.CronJob = owner.Name
+	}
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			jc.FailureReason = cond.Reason
+			jc.FailureMessage = cond.Message
+		}
+	}
+	return jc
+}
+
+// jobTemplatePod returns the pod to clone for a pod of a Job: the pod with
+// the spec of the Job template, which is what the Job runs on a retry,
+// without what admission and the kubelet added to the pod.
+func jobTemplatePod(pod *corev1.Pod, job *batchv1.Job) *corev1.Pod {
+	if job == nil {
+		return pod
+	}
+	clone := pod.DeepCopy()
+	clone.Spec = *job.Spec.Template.Spec.DeepCopy()
+	return clone
+}
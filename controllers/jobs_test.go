@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+// cronJobRun is the Job and pod of one run of the nightly-migration CronJob
+func cronJobRun(job string) (*batchv1.Job, *corev1.Pod) {
+	controller := true
+	backoffLimit := int32(2)
+	deadline := int64(600)
+	j := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job,
+			Namespace: "default",
+			UID:       types.UID(job + "-uid"),
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "batch/v1", Kind: "CronJob", Name: "nightly-migration", Controller: &controller},
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				RestartPolicy: corev1.RestartPolicyNever,
+				Containers:    []corev1.Container{{Name: "migrator", Image: "postgres:15-alpine", Command: []string{"/migrate.sh"}}},
+			}},
+		},
+		Status: batchv1.JobStatus{
+			Failed: 3,
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+			},
+		},
+	}
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job + "-x7k2p",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "batch/v1", Kind: "Job", Name: job, UID: j.UID, Controller: &controller},
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []corev1.Container{{Name: "migrator", Image: "postgres:15-alpine", Command: []string{"/migrate.sh"}}},
+			Volumes:    []corev1.Volume{{Name: "kube-api-access-abcde"}},
+		},
+	}
+	return j, p
+}
+
+func TestResolveWorkloadCronJob(t *testing.T) {
+	ctx := context.Background()
+	job1, pod1 := cronJobRun("nightly-migration-29001440")
+	job2, pod2 := cronJobRun("nightly-migration-29002880")
+	r := &PodReconciler{KubeClient: kubefake.NewSimpleClientset(job1, job2)}
+
+	workload1, err := r.resolveWorkload(ctx, pod1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workload1.String() != "CronJob/nightly-migration" || workload1.Job == nil || workload1.Job.Name != job1.Name {
+		t.Fatalf("got (%s, %v), want the CronJob and the Job of the pod", workload1, workload1.Job)
+	}
+	workload2, _ := r.resolveWorkload(ctx, pod2)
+	if r.getCrashSignature(pod1, workload1.String(), "migrator", 1, nil) != r.getCrashSignature(pod2, workload2.String(), "migrator", 1, nil) {
+		t.Error("two runs of a CronJob got different signatures")
+	}
+
+	// The Job was deleted: fall back to the owner reference
+	r = &PodReconciler{KubeClient: kubefake.NewSimpleClientset()}
+	if workload, err := r.resolveWorkload(ctx, pod1); err != nil || workload.String() != "Job/"+job1.Name || workload.Job != nil {
+		t.Errorf("got (%s, %v), want the Job name from the owner reference", workload, workload.Job)
+	}
+}
+
+func TestJobContext(t *testing.T) {
+	job, pod := cronJobRun("nightly-migration-29001440")
+
+	jc := jobContext(job)
+	if jc.Name != job.Name || jc.CronJob != "nightly-migration" {
+		t.Errorf("job %q of cronjob %q", jc.Name, jc.CronJob)
+	}
+	if *jc.BackoffLimit != 2 || *jc.ActiveDeadlineSeconds != 600 || jc.Completions != nil || jc.Failed != 3 {
+		t.Errorf("unexpected job settings %+v", jc)
+	}
+	if jc.FailureReason != "BackoffLimitExceeded" {
+		t.Errorf("failure reason %q, want BackoffLimitExceeded", jc.FailureReason)
+	}
+
+	clone := jobTemplatePod(pod, job)
+	if len(clone.Spec.Volumes) != 0 || clone.Spec.NodeName != "" || clone.Name != pod.Name {
+		t.Errorf("clone not built from the Job template: %+v", clone.Spec)
+	}
+	if len(pod.Spec.Volumes) != 1 {
+		t.Error("source pod modified")
+	}
+	if jobTemplatePod(pod, nil) != pod {
+		t.Error("pods without a Job are cloned as they are")
+	}
+}
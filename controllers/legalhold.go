@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"kube-forensics-controller/pkg/storage"
+)
+
+const (
+	// AnnotationSourcePod is the <namespace>/<name> of the crashed pod, set on forensic pods
+	AnnotationSourcePod = "forensic.io/source-pod"
+	// AnnotationLegalHold records the legal hold status (ON or OFF) last applied
+	// to the exported artifacts of a forensic pod
+	AnnotationLegalHold = "forensic.io/legal-hold"
+)
+
+// Legal hold status values of AnnotationLegalHold
+const (
+	legalHoldOn  = "ON"
+	legalHoldOff = "OFF"
+)
+
+// syncLegalHold maps the forensic.io/hold annotation of a forensic pod to an
+// S3 legal hold on every artifact of its capture. Failures are reported as
+// events on the source pod and retried on the next update of the forensic pod.
+func (r *PodReconciler) syncLegalHold(ctx context.Context, pod *corev1.Pod) {
+	prefix := pod.Annotations[AnnotationStoragePrefix]
+	if !r.Config.Storage.S3LegalHold || prefix == "" {
+		return
+	}
+	want := legalHoldOff
+	if pod.Annotations[AnnotationForensicHold] == "true" {
+		want = legalHoldOn
+	}
+	applied := pod.Annotations[AnnotationLegalHold]
+	if applied == want || (applied == "" && want == legalHoldOff) {
+		return
+	}
+
+	logger := log.FromContext(ctx)
+	locker, ok := storage.AsObjectLocker(r.Storage)
+	if !ok {
+		return // Rejected at startup for other backends
+	}
+
+	objects, err := r.Storage.List(ctx, prefix)
+	if err == nil {
+		for _, o := range objects {
+			if err = locker.SetLegalHold(ctx, o.Key, want == legalHoldOn); err != nil {
+				err = fmt.Errorf("%s: %w", o.Key, err)
+				break
+			}
+		}
+	}
+	if err != nil {
+		logger.Error(err, "Failed to update legal hold", "pod", pod.Name, "status", want)
+		reason := "ForensicLegalHoldFailed"
+		if errors.Is(err, storage.ErrObjectLockDisabled) {
+			reason = "ForensicObjectLockFailed"
+		}
+		r.Recorder.Eventf(sourcePodRef(pod), corev1.EventTypeWarning, reason, "Failed to set legal hold %s on the artifacts of forensic pod %s: %v", want, pod.Name, err)
+		return
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.Annotations[AnnotationLegalHold] = want
+	if err := r.Patch(ctx, pod, patch); err != nil {
+		logger.Error(err, "Failed to record legal hold", "pod", pod.Name)
+		return
+	}
+	r.Recorder.Eventf(sourcePodRef(pod), corev1.EventTypeNormal, "ForensicLegalHold", "Legal hold %s on %d artifacts of forensic pod %s", want, len(objects), pod.Name)
+}
+
+// sourcePodRef returns a reference to the crashed pod of a forensic pod, for
+// events. Forensic pods created before AnnotationSourcePod fall back to themselves.
+func sourcePodRef(forensicPod *corev1.Pod) *corev1.Pod {
+	ns, name, ok := strings.Cut(forensicPod.Annotations[AnnotationSourcePod], "/")
+	if !ok {
+		return forensicPod
+	}
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: ns,
+		Name:      name,
+		UID:       types.UID(forensicPod.Labels[LabelSourcePodUID]),
+	}}
+}
+
+// exportFailedReason is the event reason of a failed upload
+func exportFailedReason(err error) string {
+	if errors.Is(err, storage.ErrObjectLockDisabled) {
+		return "ForensicObjectLockFailed"
+	}
+	return "ForensicExportFailed"
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kube-forensics-controller/pkg/storage"
+)
+
+// lockingProvider records legal holds on top of a filesystem provider
+type lockingProvider struct {
+	*storage.FilesystemProvider
+	holds map[string]bool
+	err   error
+}
+
+func (p *lockingProvider) CheckObjectLock(ctx context.Context) error { return p.err }
+
+func (p *lockingProvider) SetLegalHold(ctx context.Context, key string, on bool) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.holds[key] = on
+	return nil
+}
+
+func TestSyncLegalHold(t *testing.T) {
+	ctx := context.Background()
+	fs, err := storage.NewFilesystemProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &lockingProvider{FilesystemProvider: fs, holds: map[string]bool{}}
+	for _, key := range []string{"prod/web/2026/01/02/100405/app.current.log", "prod/web/2026/01/02/100405/manifest.json", "prod/web/2026/03/04/100405/app.current.log"} {
+		if _, err := provider.Upload(ctx, key, []byte("x"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-forensic-abc",
+			Namespace: "debug-forensics",
+			Labels:    map[string]string{LabelSourcePodUID: "web-uid"},
+			Annotations: map[string]string{
+				AnnotationForensicHold:  "true",
+				AnnotationStoragePrefix: "prod/web/2026/01/02/100405/",
+				AnnotationSourcePod:     "prod/web",
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &PodReconciler{
+		Client:   c,
+		Storage:  provider,
+		Recorder: recorder,
+		Config:   ForensicsConfig{TargetNamespace: "debug-forensics", Storage: storage.Config{S3LegalHold: true}},
+	}
+
+	sync := func() *corev1.Pod {
+		t.Helper()
+		var got corev1.Pod
+		if err := c.Get(ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, &got); err != nil {
+			t.Fatal(err)
+		}
+		r.syncLegalHold(ctx, &got)
+		if err := c.Get(ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, &got); err != nil {
+			t.Fatal(err)
+		}
+		return &got
+	}
+	holds := func() string {
+		var keys []string
+		for k, on := range provider.holds {
+			keys = append(keys, fmt.Sprintf("%s=%v", k, on))
+		}
+		sort.Strings(keys)
+		return strings.Join(keys, ",")
+	}
+
+	// Only the artifacts of the capture are held
+	got := sync()
+	if got.Annotations[AnnotationLegalHold] != legalHoldOn {
+		t.Errorf("legal hold annotation = %q, want ON", got.Annotations[AnnotationLegalHold])
+	}
+	if want := "prod/web/2026/01/02/100405/app.current.log=true,prod/web/2026/01/02/100405/manifest.json=true"; holds() != want {
+		t.Errorf("holds = %s, want %s", holds(), want)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ForensicLegalHold") {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	// Already applied: nothing to do
+	provider.holds = map[string]bool{}
+	sync()
+	if len(provider.holds) != 0 {
+		t.Errorf("legal hold applied twice: %s", holds())
+	}
+
+	// Removing the hold releases it
+	delete(got.Annotations, AnnotationForensicHold)
+	if err := c.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	got = sync()
+	if got.Annotations[AnnotationLegalHold] != legalHoldOff {
+		t.Errorf("legal hold annotation = %q, want OFF", got.Annotations[AnnotationLegalHold])
+	}
+	if want := "prod/web/2026/01/02/100405/app.current.log=false,prod/web/2026/01/02/100405/manifest.json=false"; holds() != want {
+		t.Errorf("holds = %s, want %s", holds(), want)
+	}
+	<-recorder.Events
+
+	// A bucket without Object Lock is reported on the source pod
+	got.Annotations[AnnotationForensicHold] = "true"
+	if err := c.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	provider.err = fmt.Errorf("%w: evidence", storage.ErrObjectLockDisabled)
+	got = sync()
+	if got.Annotations[AnnotationLegalHold] != legalHoldOff {
+		t.Errorf("failed legal hold recorded as %q", got.Annotations[AnnotationLegalHold])
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ForensicObjectLockFailed") {
+		t.Errorf("unexpected event %q", event)
+	}
+	if ref := sourcePodRef(got); ref.Namespace != "prod" || ref.Name != "web" || ref.UID != "web-uid" {
+		t.Errorf("event not on the source pod: %+v", ref.ObjectMeta)
+	}
+}
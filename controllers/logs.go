@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// LogInstanceCurrent is the container instance reported in State
+	LogInstanceCurrent = "current"
+	// LogInstancePrevious is the instance reported in LastTerminationState, i.e. before the last restart
+	LogInstancePrevious = "previous"
+)
+
+// capturedLog is the log of a single container instance
+type capturedLog struct {
+	Key       string // File name in the log ConfigMap, e.g. app.previous.log
+	Container string
+	Instance  string
+	Data      string
+	ExportURL string
+}
+
+func (l capturedLog) sha256() string {
+	hash := sha256.Sum256([]byte(l.Data))
+	return hex.EncodeToString(hash[:])
+}
+
+// logKey names a log after the container and instance it was read from
+func logKey(container string, instance string) string {
+	return fmt.Sprintf("%s.%s.log", container, instance)
+}
+
+// fetchCrashLog fetches the log of the instance that crashed. When it cannot
+// be read, the error is returned and Data holds the error message instead.
+func (r *PodReconciler) fetchCrashLog(ctx context.Context, cfg ForensicsConfig, pod *corev1.Pod, containerName string, crashedInstance string) (capturedLog, error) {
+	crashed := capturedLog{
+		Key:       logKey(containerName, crashedInstance),
+		Container: containerName,
+		Instance:  crashedInstance,
+	}
+	data, err := r.getPodLogs(ctx, cfg, pod, containerName, crashedInstance == LogInstancePrevious)
+	if err != nil {
+		data = fmt.Sprintf("Error fetching logs: %v", err)
+	}
+	crashed.Data = data
+	return crashed, err
+}
+
+// collectLogs adds to the crash log every other container instance of the pod
+// the kubelet still has logs for: the other instance of the crashed container,
+// then all init, sidecar and regular containers. The crash log is always
+// first. Failures on the other logs are only logged.
+func (r *PodReconciler) collectLogs(ctx context.Context, cfg ForensicsConfig, pod *corev1.Pod, crashed capturedLog) []capturedLog {
+	logger := log.FromContext(ctx)
+	containerName, crashedInstance := crashed.Container, crashed.Instance
+	logs := []capturedLog{crashed}
+
+	// Sidecars (envoy, log shippers, proxies) often hold the real cause,
+	// so every container is captured. Native sidecars are init containers.
+	// The crashed container goes first so its other instance follows the crash log.
+	names := []string{containerName}
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name != containerName {
+			names = append(names, c.Name)
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name != containerName {
+			names = append(names, c.Name)
+		}
+	}
+
+	for _, name := range names {
+		// The other instances only exist if the kubelet reports them
+		status := findContainerStatus(pod, name)
+		if status == nil {
+			continue
+		}
+		for _, instance := range []string{LogInstancePrevious, LogInstanceCurrent} {
+			if name == containerName && instance == crashedInstance {
+				continue
+			}
+			if !instanceAvailable(status, instance) {
+				continue
+			}
+
+			data, err := r.getPodLogs(ctx, cfg, pod, name, instance == LogInstancePrevious)
+			if err != nil {
+				logger.V(1).Info("Could not fetch container logs", "container", name, "instance", instance, "error", err.Error())
+				continue
+			}
+			logs = append(logs, capturedLog{
+				Key:       logKey(name, instance),
+				Container: name,
+				Instance:  instance,
+				Data:      data,
+			})
+		}
+	}
+	return logs
+}
+
+// instanceAvailable reports whether the kubelet still holds logs for an instance
+func instanceAvailable(status *corev1.ContainerStatus, instance string) bool {
+	if instance == LogInstancePrevious {
+		return status.LastTerminationState.Terminated != nil
+	}
+	return status.State.Running != nil || status.State.Terminated != nil
+}
+
+func findContainerStatus(pod *corev1.Pod, name string) *corev1.ContainerStatus {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == name {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	for i := range pod.Status.InitContainerStatuses {
+		if pod.Status.InitContainerStatuses[i].Name == name {
+			return &pod.Status.InitContainerStatuses[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCollectLogsAllContainers(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	terminated := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "migrate"}},
+			Containers:     []corev1.Container{{Name: "envoy"}, {Name: "app"}, {Name: "never-started"}},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "migrate", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "envoy", State: running},
+				{Name: "app", State: running, LastTerminationState: terminated},
+			},
+		},
+	}
+
+	r := &PodReconciler{KubeClient: fake.NewSimpleClientset()}
+	cfg := ForensicsConfig{MaxLogSizeBytes: 1024, LogTruncationMode: LogTruncationHead}
+
+	crashLog, err := r.fetchCrashLog(context.Background(), cfg, pod, "app", LogInstancePrevious)
+	if err != nil {
+		t.Fatalf("fetchCrashLog: %v", err)
+	}
+	logs := r.collectLogs(context.Background(), cfg, pod, crashLog)
+
+	want := []string{"app.previous.log", "app.current.log", "migrate.current.log", "envoy.current.log"}
+	if len(logs) != len(want) {
+		t.Fatalf("got %d logs, want %d: %+v", len(logs), len(want), logs)
+	}
+	for i, key := range want {
+		if logs[i].Key != key {
+			t.Errorf("logs[%d].Key = %q, want %q", i, logs[i].Key, key)
+		}
+		if logs[i].Data == "" || logs[i].sha256() == "" {
+			t.Errorf("logs[%d] has no data", i)
+		}
+	}
+}
@@ -32,6 +32,24 @@ var (
 		},
 		[]string{"source_namespace", "step"},
 	)
+
+	// ForensicRuleMatchesTotal counts the crash logs matched by custom rules
+	ForensicRuleMatchesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "forensics_rule_matches_total",
+			Help: "Total number of crash logs matched by custom log rules",
+		},
+		[]string{"namespace", "rule", "severity"},
+	)
+
+	// ForensicRulePagesTotal counts the pages raised by custom rules
+	ForensicRulePagesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "forensics_rule_pages_total",
+			Help: "Total number of pages raised by custom log rules",
+		},
+		[]string{"namespace", "rule"},
+	)
 )
 
 func init() {
@@ -40,5 +58,7 @@ func init() {
 		ForensicCrashesTotal,
 		ForensicPodsCreatedTotal,
 		ForensicPodCreationErrorsTotal,
+		ForensicRuleMatchesTotal,
+		ForensicRulePagesTotal,
 	)
 }
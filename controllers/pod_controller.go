@@ -2,11 +2,15 @@ package controllers
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -25,8 +29,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
 	"kube-forensics-controller/pkg/checkpoint"
 	"kube-forensics-controller/pkg/collector"
+	"kube-forensics-controller/pkg/fingerprint"
+	"kube-forensics-controller/pkg/manifest"
+	"kube-forensics-controller/pkg/rules"
 	"kube-forensics-controller/pkg/storage"
 )
 
@@ -36,48 +44,93 @@ const (
 	LabelForensicTime           = "forensic-time"
 	LabelForensicTTL            = "forensic.io/ttl"
 	LabelCrashSignature         = "forensic.io/crash-signature"
+	LabelExitSignature          = "forensic.io/exit-signature"
+	AnnotationLogUnavailable    = "forensic.io/log-unavailable"
+	LabelForensicCase           = "forensic.io/case"
 	AnnotationNoSecretClone     = "forensic.io/no-secret-clone"
 	AnnotationForensicHold      = "forensic.io/hold"
 	AnnotationRequestCheckpoint = "forensic.io/request-checkpoint"
 	LabelLogS3URL               = "forensic.io/log-s3-url"
+	AnnotationStoragePrefix     = "forensic.io/storage-prefix"
 	ForensicTimeFormat          = "2006-01-02T15-04-05Z"
 	NetworkPolicyName           = "deny-all-egress"
 	LogConfigMapKey             = "crash.log"
+	AnnotationCrashLogKey       = "forensic.io/crash-log-key"
 )
 
 type ForensicsConfig struct {
-	TargetNamespace     string
-	ForensicTTL         time.Duration
-	MaxLogSizeBytes     int64
-	IgnoreNamespaces    []string
-	WatchNamespaces     []string
-	EnableSecretCloning bool
-	EnableCheckpointing bool
-	RateLimitWindow     time.Duration
-	S3Bucket            string
-	S3Region            string
-	Image               string // Controller image for collector job
+	TargetNamespace      string
+	ForensicTTL          time.Duration
+	ArtifactRetention    time.Duration // Age after which exported artifacts are deleted; 0 keeps them
+	MaxLogSizeBytes      int64
+	LogTruncationMode    string // LogTruncationHead or LogTruncationHeadTail
+	LogHeadBytes         int64  // Head window kept in head-tail mode
+	LargeLogEvidence     string // EvidenceChunked or EvidencePVC, for logs that do not fit one ConfigMap
+	EvidenceStorageClass string // StorageClass of evidence PVCs; empty uses the default
+	IgnoreNamespaces     []string
+	WatchNamespaces      []string
+	EnableSecretCloning  bool
+	EnableCheckpointing  bool
+	RateLimitWindow      time.Duration
+	// Restart-based crash criteria; a zero threshold disables its criterion
+	CrashLoopMinRestarts  int32 // Restarts after which a CrashLoopBackOff wait is a crash
+	RestartDeltaThreshold int32 // Restarts since the last capture of a container
+	RestartRateThreshold  int32 // Restarts within RestartRateWindow
+	RestartRateWindow     time.Duration
+	// Pods that never start are recorded in a case without a forensic pod
+	CaptureStartupFailures bool
+	UnschedulableThreshold time.Duration // How long a pod may stay unschedulable before it is captured
+	EnableSnapshots        bool
+	EnableExport           bool
+	EnableToolkit          bool
+	SigningKeySecret       string             // Secret holding the manifest signing key; empty disables signing
+	SigningKeyNamespace    string             // Namespace of the signing key Secret (the controller's own)
+	RulesConfigMap         string             // ConfigMap holding custom log rules; empty disables them
+	RulesNamespace         string             // Namespace of the rules ConfigMap (the controller's own)
+	Storage                storage.Config     // Backend settings passed on to the collector job
+	StorageKeyTemplate     *template.Template // Storage prefix of a capture, see ParseStorageKeyTemplate; nil uses the default
+	ClusterName            string             // Recorded in the metadata of exported artifacts
+	Image                  string             // Controller image for collector job
 }
 
 // PodReconciler reconciles a Pod object
 type PodReconciler struct {
 	client.Client
+	// APIReader reads objects straight from the API server, for objects this
+	// reconcile has just written and the cache may not have seen yet
+	APIReader        client.Reader
 	Scheme           *runtime.Scheme
 	KubeClient       kubernetes.Interface
 	Config           ForensicsConfig
 	Recorder         record.EventRecorder
 	Storage          storage.Provider
 	CheckpointClient *checkpoint.Client
+	// Rules are the custom log rules, kept in sync with Config.RulesConfigMap
+	Rules *rules.Engine
+
+	signingMu sync.Mutex
+	signer    ed25519.PrivateKey
+
+	restarts restartTracker
 }
 
 //+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=pods/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups="",resources=pods/log,verbs=get
 //+kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;create;delete
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create
 //+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups="",resources=nodes/proxy,verbs=get;create
 //+kubebuilder:rbac:groups="batch",resources=jobs,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="batch",resources=cronjobs,verbs=get
+//+kubebuilder:rbac:groups=argoproj.io,resources=rollouts,verbs=get
+//+kubebuilder:rbac:groups=apps,resources=replicasets;deployments;statefulsets;daemonsets,verbs=get
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=list;create;patch
+//+kubebuilder:rbac:groups=forensic.io,resources=forensiccases,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=forensic.io,resources=forensiccases/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=forensic.io,resources=forensicpolicies,verbs=get;list;watch
 
 func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -103,7 +156,8 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		}
 	}
 	if req.Namespace == r.Config.TargetNamespace {
-		return ctrl.Result{}, nil
+		// Forensic pods only feed their phase and hold back into their case and artifacts
+		return r.syncForensicCase(ctx, req)
 	}
 
 	// 1. Fetch the Pod
@@ -144,8 +198,8 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 					r.Recorder.Eventf(&pod, corev1.EventTypeNormal, "ForensicCheckpointCreated", "On-demand checkpoint created at %s", loc)
 
 					// Launch Collector
-					if r.Config.S3Bucket != "" {
-						r.launchCollector(ctx, &pod, loc)
+					if r.Config.Storage.Enabled() {
+						r.launchCollector(ctx, &pod, targetContainer, loc)
 					}
 				}
 			} else {
@@ -169,6 +223,8 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	// 3. Check Crash Criteria
 	isCrash := false
 	crashedContainerName := ""
+	crashReason := ""
+	crashedInstance := LogInstanceCurrent
 	var exitCode int32 = 0
 
 	checkStatus := func(name string, state corev1.ContainerState, lastState corev1.ContainerState) bool {
@@ -176,6 +232,7 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 			reason := state.Terminated.Reason
 			if reason == "Error" || reason == "OOMKilled" || state.Terminated.ExitCode != 0 {
 				crashedContainerName = name
+				crashReason = reason
 				exitCode = state.Terminated.ExitCode
 				return true
 			}
@@ -184,6 +241,8 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 			reason := lastState.Terminated.Reason
 			if reason == "Error" || reason == "OOMKilled" || lastState.Terminated.ExitCode != 0 {
 				crashedContainerName = name
+				crashReason = reason
+				crashedInstance = LogInstancePrevious // The container has restarted since
 				exitCode = lastState.Terminated.ExitCode
 				return true
 			}
@@ -191,16 +250,49 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return false
 	}
 
+	// 3.0 Evictions
+	// Evicted pods often have no terminated container, the reason is on the pod
+	evicted := isEvicted(&pod)
+	if evicted {
+		isCrash = true
+		crashReason = ReasonEvicted
+		crashedContainerName, exitCode = evictedContainer(&pod)
+	}
+
 	allStatuses := append(pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses...)
 	for _, status := range allStatuses {
-		if checkStatus(status.Name, status.State, status.LastTerminationState) {
+		if !evicted && checkStatus(status.Name, status.State, status.LastTerminationState) {
 			isCrash = true
 			break
 		}
 	}
 
+	// 3.0.1 Restart Loops
+	// Containers that exit 0 in a loop never terminate with an error. Every
+	// container is observed, so restarts are counted whichever criterion fires.
+	restartReasons := make(map[string]string)
+	for _, status := range allStatuses {
+		if reason := r.restartCrash(&pod, status, time.Now()); reason != "" {
+			restartReasons[status.Name] = reason
+		}
+	}
+	for _, status := range allStatuses {
+		if isCrash || restartReasons[status.Name] == "" {
+			continue
+		}
+		isCrash = true
+		crashedContainerName = status.Name
+		crashReason = restartReasons[status.Name]
+		if last := status.LastTerminationState.Terminated; last != nil {
+			crashedInstance = LogInstancePrevious
+			exitCode = last.ExitCode
+		}
+	}
+
 	if !isCrash && pod.Status.Phase == corev1.PodFailed {
+		// Evictions were handled above and keep the exit code of their container
 		isCrash = true
+		crashReason = pod.Status.Reason
 		if len(pod.Spec.Containers) > 0 {
 			crashedContainerName = pod.Spec.Containers[0].Name
 			exitCode = 1
@@ -208,24 +300,78 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	}
 
 	if !isCrash {
+		// 3.0.2 Startup Failures
+		// Pods that never start have nothing to clone, they get a case only
+		if r.Config.CaptureStartupFailures {
+			failure, wait := detectStartupFailure(&pod, r.Config.UnschedulableThreshold, time.Now())
+			if failure != nil {
+				return r.captureStartupFailure(ctx, &pod, failure)
+			}
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// A crash a custom rule skipped was reported once already
+	if captureSkipped(&pod, crashedContainerName) {
+		logger.V(1).Info("Skipping crash (capture skipped by a custom rule)", "pod", req.NamespacedName)
 		return ctrl.Result{}, nil
 	}
 
 	logger.Info("Detected crashed pod", "pod", req.NamespacedName, "phase", pod.Status.Phase)
-	ForensicCrashesTotal.WithLabelValues(pod.Namespace, "CrashDetected").Inc()
+	metricReason := "CrashDetected"
+	if evicted {
+		metricReason = ReasonEvicted
+	}
+	ForensicCrashesTotal.WithLabelValues(pod.Namespace, metricReason).Inc()
+
+	// 3.1 Resolve Policy
+	cfg, policyName, err := r.resolveConfig(ctx, &pod)
+	if err != nil {
+		logger.Error(err, "Failed to resolve forensic policy (using global config)")
+	} else if policyName != "" {
+		logger.Info("Applying forensic policy", "policy", policyName)
+	}
+
+	// 3.2 Fetch the Crash Log
+	// The signature covers the stack trace of the crash, so its log is read
+	// first. The logs of the other containers wait until it is captured.
+	crashLog, logErr := r.fetchCrashLog(ctx, cfg, &pod, crashedContainerName, crashedInstance)
+	if logErr != nil {
+		logger.Error(logErr, "Failed to fetch logs (continuing without logs)")
+	}
+	var stack *fingerprint.Fingerprint
+	var crashLogData string // Empty when the log could not be read
+	if logErr == nil {
+		crashLogData = crashLog.Data
+		stack = fingerprint.Extract(crashLogData)
+	}
 
 	// 4. Deduplication
-	signature := r.getCrashSignature(&pod, crashedContainerName, exitCode)
-	var forensicPods corev1.PodList
-	if err := r.List(ctx, &forensicPods, client.InNamespace(r.Config.TargetNamespace), client.MatchingLabels{LabelCrashSignature: signature}); err != nil {
+	workload, err := r.resolveWorkload(ctx, &pod)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
-	now := time.Now()
-	for _, fp := range forensicPods.Items {
-		age := now.Sub(fp.CreationTimestamp.Time)
-		if age < r.Config.RateLimitWindow {
-			logger.Info("Skipping forensic creation (rate limited)", "original_pod", req.NamespacedName)
-			return ctrl.Result{}, nil
+	signature := r.getCrashSignature(&pod, workload.String(), crashedContainerName, exitCode, stack)
+	exitSignature := r.getCrashSignature(&pod, workload.String(), crashedContainerName, exitCode, nil)
+	captured, err := r.recentlyCaptured(ctx, cfg.RateLimitWindow, signature, exitSignature, logErr == nil)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if captured {
+		logger.Info("Skipping forensic creation (rate limited)", "original_pod", req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	// 4.1 Custom Rules
+	// A skipped crash has no forensic pod to rate limit it, so the skip is
+	// recorded on the source pod before the crash is reported
+	matches := r.Rules.Match(crashLogData)
+	hold, skip := ruleActions(matches)
+	if skip {
+		if err := r.markCaptureSkipped(ctx, &pod, crashedContainerName); err != nil {
+			logger.Error(err, "Failed to record the skipped capture on the pod")
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -245,84 +391,265 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, err
 	}
 
-	// 7. Fetch Logs
-	logs, err := r.getPodLogs(ctx, &pod, crashedContainerName)
+	// 6.1 Record the Case
+	// The case lives in the target namespace, so it is created once the namespace exists.
+	// Its status is written once, whichever way the capture ends.
+	fcase, err := r.createForensicCase(ctx, &pod, signature, workload, crashedContainerName, exitCode, crashReason, policyName)
 	if err != nil {
-		logger.Error(err, "Failed to fetch logs (continuing without logs)")
-		logs = fmt.Sprintf("Error fetching logs: %v", err)
+		logger.Error(err, "Failed to create forensic case (continuing without case)")
+		ForensicPodCreationErrorsTotal.WithLabelValues(pod.Namespace, "CreateForensicCase").Inc()
+	}
+	defer r.saveCaseStatus(ctx, fcase)
+
+	// 6.2 Eviction Evidence
+	// Read first, while the node may still be under pressure
+	if evicted && fcase != nil {
+		fcase.Status.Eviction = r.evictionEvidence(ctx, &pod)
+	}
+	if workload.Job != nil && fcase != nil {
+		fcase.Status.Job = jobContext(workload.Job)
+	}
+	if stack != nil && fcase != nil {
+		fcase.Status.Exception = &forensicv1alpha1.Exception{
+			Language:    stack.Language,
+			Type:        stack.Type,
+			Message:     stack.Message,
+			Frames:      stack.Frames,
+			Fingerprint: stack.Hash,
+		}
+	}
+
+	// 6.3 Probable Cause
+	cause := probableCause(&pod, crashedContainerName, crashedInstance, crashReason, exitCode, crashLogData)
+	if fcase != nil {
+		fcase.Status.ProbableCause = caseProbableCause(cause)
+	}
+	r.Recorder.Event(&pod, corev1.EventTypeWarning, "ForensicProbableCause", cause.Message())
+
+	// 6.4 Custom Rules
+	r.applyRules(&pod, fcase, matches)
+	if skip {
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionForensicPodRunning, metav1.ConditionFalse, "CaptureSkipped", "A custom rule skipped the capture")
+		return ctrl.Result{}, nil
 	}
 
-	// 8. Upload Logs to S3
-	var s3URL string
-	if logs != "" {
-		timestamp := time.Now().UTC().Format("2006/01/02/150405")
-		key := fmt.Sprintf("%s/%s/%s/crash.log", pod.Namespace, pod.Name, timestamp)
-		url, err := r.Storage.Upload(ctx, key, []byte(logs))
+	// 7. Fetch the Logs of the Other Containers
+	capturedLogs := r.collectLogs(ctx, cfg, &pod, crashLog)
+
+	// 7.1 Assemble Evidence Bundle while the cluster state is still fresh
+	var evidenceBundle []byte
+	if cfg.EnableExport {
+		evidenceBundle, err = r.buildEvidenceBundle(ctx, &pod).Bytes()
 		if err != nil {
-			logger.Error(err, "Failed to upload logs to S3")
-			r.Recorder.Eventf(&pod, corev1.EventTypeWarning, "ForensicExportFailed", "Failed to upload logs to S3: %v", err)
-		} else if url != "" {
-			s3URL = url
-			r.Recorder.Eventf(&pod, corev1.EventTypeNormal, "ForensicExportSuccess", "Uploaded logs to %s", url)
+			logger.Error(err, "Failed to assemble evidence bundle (continuing without bundle)")
+		}
+	}
+
+	// 8. Upload Logs and Evidence Bundle to Storage
+	// All artifacts of a capture share one prefix
+	exportPrefix := r.capturePrefix(&pod, time.Now())
+	var s3URL, bundleURL string
+	if !cfg.EnableExport {
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionUploaded, metav1.ConditionFalse, "ExportDisabled", "Export is disabled by policy")
+	} else {
+		var uploadErr error
+		uploaded := 0
+		for i := range capturedLogs {
+			key := fmt.Sprintf("%s/%s", exportPrefix, capturedLogs[i].Key)
+			meta := r.artifactMetadata(&pod, capturedLogs[i].Container, &exitCode, signature, capturedLogs[i].sha256())
+			url, err := r.Storage.Upload(ctx, key, []byte(capturedLogs[i].Data), meta)
+			if err != nil {
+				logger.Error(err, "Failed to upload logs to storage", "log", capturedLogs[i].Key)
+				r.Recorder.Eventf(&pod, corev1.EventTypeWarning, exportFailedReason(err), "Failed to upload %s logs of container %s to storage: %v", capturedLogs[i].Instance, capturedLogs[i].Container, err)
+				uploadErr = err
+				continue
+			}
+			if url != "" {
+				capturedLogs[i].ExportURL = url
+				uploaded++
+				r.Recorder.Eventf(&pod, corev1.EventTypeNormal, "ForensicExportSuccess", "Uploaded %s logs of container %s to %s", capturedLogs[i].Instance, capturedLogs[i].Container, url)
+			}
+		}
+		s3URL = capturedLogs[0].ExportURL
+
+		if evidenceBundle != nil {
+			meta := r.artifactMetadata(&pod, crashedContainerName, &exitCode, signature, manifest.Hash(evidenceBundle))
+			url, err := r.Storage.Upload(ctx, fmt.Sprintf("%s/%s", exportPrefix, EvidenceBundleName), evidenceBundle, meta)
+			if err != nil {
+				logger.Error(err, "Failed to upload evidence bundle to storage")
+				r.Recorder.Eventf(&pod, corev1.EventTypeWarning, exportFailedReason(err), "Failed to upload evidence bundle to storage: %v", err)
+				uploadErr = err
+			} else if url != "" {
+				uploaded++
+				bundleURL = url
+				if fcase != nil {
+					fcase.Status.EvidenceBundleURL = url
+				}
+				r.Recorder.Eventf(&pod, corev1.EventTypeNormal, "ForensicExportSuccess", "Uploaded evidence bundle to %s", url)
+			}
+		}
+
+		if uploadErr != nil {
+			r.setCaseCondition(fcase, forensicv1alpha1.ConditionUploaded, metav1.ConditionFalse, "UploadFailed", uploadErr.Error())
+		} else if uploaded > 0 {
+			if fcase != nil {
+				fcase.Status.ExportURL = s3URL
+			}
+			r.setCaseCondition(fcase, forensicv1alpha1.ConditionUploaded, metav1.ConditionTrue, "Uploaded", fmt.Sprintf("Uploaded %d artifacts to %s", uploaded, exportPrefix))
+		} else {
+			r.setCaseCondition(fcase, forensicv1alpha1.ConditionUploaded, metav1.ConditionFalse, "ExportDisabled", "No storage backend is configured")
 		}
 	}
 
 	// 9. Clone ConfigMaps and Secrets
-	resourceMap, err := r.cloneDependencies(ctx, &pod)
+	resourceMap, err := r.cloneDependencies(ctx, cfg, &pod)
 	if err != nil {
 		logger.Error(err, "Failed to clone dependencies")
 		ForensicPodCreationErrorsTotal.WithLabelValues(pod.Namespace, "CloneDependencies").Inc()
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionDependenciesCloned, metav1.ConditionFalse, "CloneFailed", err.Error())
 		return ctrl.Result{}, err
 	}
+	if fcase != nil {
+		for src, dst := range resourceMap {
+			fcase.Status.ClonedResources = append(fcase.Status.ClonedResources, fmt.Sprintf("%s=%s", src, dst))
+		}
+		sort.Strings(fcase.Status.ClonedResources)
+	}
+	r.setCaseCondition(fcase, forensicv1alpha1.ConditionDependenciesCloned, metav1.ConditionTrue, "Cloned", fmt.Sprintf("Cloned %d ConfigMaps and Secrets", len(resourceMap)))
 
-	// 10. Create Log ConfigMap
-	logCMName, err := r.createLogConfigMap(ctx, &pod, logs)
-	if err != nil {
-		logger.Error(err, "Failed to create log configmap")
+	// 10. Store Log Evidence
+	// A failure here does not abort the capture: the forensic pod is still
+	// created, with an empty log directory.
+	evidence, storeErr := r.storeLogEvidence(ctx, &pod, capturedLogs)
+	if storeErr != nil {
+		logger.Error(storeErr, "Failed to store log evidence (continuing without logs)")
 		ForensicPodCreationErrorsTotal.WithLabelValues(pod.Namespace, "CreateLogCM").Inc()
-		return ctrl.Result{}, err
+		r.Recorder.Eventf(&pod, corev1.EventTypeWarning, "ForensicLogStoreFailed", "Failed to store logs in %s: %v", r.Config.TargetNamespace, storeErr)
 	}
 
 	// Calculate Log Hash
-	logHash := sha256.Sum256([]byte(logs))
-	logHashStr := hex.EncodeToString(logHash[:])
+	logHashStr := crashLog.sha256()
+
+	if fcase != nil {
+		if evidence != nil {
+			fcase.Status.LogConfigMap = evidence.ConfigMaps[0]
+			fcase.Status.LogStorage = evidence.Mode
+			if evidence.Mode != EvidenceConfigMap {
+				fcase.Status.LogConfigMaps = evidence.ConfigMaps
+			}
+			fcase.Status.LogPVC = evidence.PVC
+		}
+		fcase.Status.LogSHA256 = logHashStr
+		for _, l := range capturedLogs {
+			fcase.Status.Logs = append(fcase.Status.Logs, forensicv1alpha1.LogArtifact{
+				Key:       l.Key,
+				Container: l.Container,
+				Instance:  l.Instance,
+				SHA256:    l.sha256(),
+				ExportURL: l.ExportURL,
+			})
+		}
+	}
+	if storeErr != nil {
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionLogsCaptured, metav1.ConditionFalse, "StoreFailed", storeErr.Error())
+	} else if logErr != nil {
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionLogsCaptured, metav1.ConditionFalse, "FetchFailed", logErr.Error())
+	} else {
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionLogsCaptured, metav1.ConditionTrue, "Captured", fmt.Sprintf("Stored %s logs of container %s in %s", crashedInstance, crashedContainerName, evidence.describe()))
+	}
 
 	// 11. Snapshot PVCs
-	snapshotMap, err := r.snapshotPVCs(ctx, &pod)
-	if err != nil {
+	var snapshotMap map[string]string
+	if cfg.EnableSnapshots {
+		snapshotMap, err = r.snapshotPVCs(ctx, cfg, &pod)
+	}
+	if !cfg.EnableSnapshots {
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionSnapshotsReady, metav1.ConditionFalse, "SnapshotsDisabled", "Volume snapshots are disabled by policy")
+	} else if err != nil {
 		logger.Error(err, "Failed to snapshot PVCs")
 		r.Recorder.Eventf(&pod, corev1.EventTypeWarning, "ForensicSnapshotFailed", "Failed to snapshot PVCs: %v", err)
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionSnapshotsReady, metav1.ConditionFalse, "SnapshotFailed", err.Error())
 	} else if len(snapshotMap) > 0 {
 		r.Recorder.Eventf(&pod, corev1.EventTypeNormal, "ForensicSnapshotsCreated", "Created volume snapshots for %d PVCs", len(snapshotMap))
+		if fcase != nil {
+			for pvc, snap := range snapshotMap {
+				fcase.Status.Snapshots = append(fcase.Status.Snapshots, forensicv1alpha1.SnapshotReference{PVC: pvc, Snapshot: snap})
+			}
+			sort.Slice(fcase.Status.Snapshots, func(i, j int) bool { return fcase.Status.Snapshots[i].PVC < fcase.Status.Snapshots[j].PVC })
+		}
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionSnapshotsReady, metav1.ConditionTrue, "SnapshotsCreated", fmt.Sprintf("Created volume snapshots for %d PVCs", len(snapshotMap)))
+	} else {
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionSnapshotsReady, metav1.ConditionTrue, "NoPVCs", "Source pod has no PersistentVolumeClaims")
 	}
 
 	// 12. Checkpointing (SKIPPED FOR CRASHES)
 	// We deliberately skip automated checkpointing for crashed pods because the process is dead.
 	var checkpointLocation string
 
+	caseName := ""
+	if fcase != nil {
+		caseName = fcase.Name
+	}
+
+	// 12.1 Sign Evidence Manifest
+	var manifestCM string
+	if r.Config.SigningKeySecret != "" {
+		m := r.buildManifest(ctx, &pod, caseName, signature, capturedLogs, evidence, evidenceBundle, bundleURL, resourceMap, snapshotMap, checkpointLocation)
+		var signed []byte
+		manifestCM, signed, err = r.storeManifest(ctx, &pod, m)
+		if err != nil {
+			logger.Error(err, "Failed to sign evidence manifest (continuing without manifest)")
+			ForensicPodCreationErrorsTotal.WithLabelValues(pod.Namespace, "SignManifest").Inc()
+			r.Recorder.Eventf(&pod, corev1.EventTypeWarning, "ForensicManifestFailed", "Failed to sign evidence manifest: %v", err)
+		} else {
+			if fcase != nil {
+				fcase.Status.ManifestConfigMap = manifestCM
+			}
+			if cfg.EnableExport {
+				meta := r.artifactMetadata(&pod, crashedContainerName, &exitCode, signature, manifest.Hash(signed))
+				url, err := r.Storage.Upload(ctx, fmt.Sprintf("%s/%s", exportPrefix, ManifestKey), signed, meta)
+				if err != nil {
+					logger.Error(err, "Failed to upload evidence manifest to storage")
+					r.Recorder.Eventf(&pod, corev1.EventTypeWarning, exportFailedReason(err), "Failed to upload evidence manifest to storage: %v", err)
+				} else if url != "" && fcase != nil {
+					fcase.Status.ManifestURL = url
+				}
+			}
+		}
+	}
+
 	// 13. Create Forensic Pod
-	if err := r.createForensicPod(ctx, &pod, resourceMap, logCMName, signature, crashedContainerName, exitCode, logHashStr, snapshotMap, checkpointLocation, s3URL); err != nil {
+	// Pods of a Job are cloned from the Job template, so the failed run can be retried by hand
+	forensicPodName, err := r.createForensicPod(ctx, cfg, jobTemplatePod(&pod, workload.Job), resourceMap, evidence, capturedLogs, logErr == nil, signature, exitSignature, workload, crashedContainerName, exitCode, cause.Category, hold, logHashStr, snapshotMap, checkpointLocation, s3URL, exportPrefix, caseName, manifestCM)
+	if err != nil {
 		logger.Error(err, "Failed to create forensic pod")
 		ForensicPodCreationErrorsTotal.WithLabelValues(pod.Namespace, "CreateForensicPod").Inc()
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionForensicPodRunning, metav1.ConditionFalse, "CreateFailed", err.Error())
 		return ctrl.Result{}, err
 	}
+	if fcase != nil {
+		fcase.Status.ForensicPod = forensicPodName
+		fcase.Status.CheckpointLocation = checkpointLocation
+	}
+	r.setCaseCondition(fcase, forensicv1alpha1.ConditionForensicPodRunning, metav1.ConditionFalse, "Created", fmt.Sprintf("Created forensic pod %s", forensicPodName))
 
 	logger.Info("Successfully created forensic pod", "original_pod", req.NamespacedName, "log_hash", logHashStr)
 	r.Recorder.Eventf(&pod, corev1.EventTypeNormal, "ForensicPodCreated", "Created forensic pod %s (LogHash: %s)", r.Config.TargetNamespace, logHashStr)
 	ForensicPodsCreatedTotal.WithLabelValues(pod.Namespace).Inc()
+	r.restartsCaptured(&pod, crashedContainerName)
 	return ctrl.Result{}, nil
 }
 
-func (r *PodReconciler) launchCollector(ctx context.Context, pod *corev1.Pod, checkpointPath string) {
-	s3Key := fmt.Sprintf("%s/%s/%s/checkpoint.tar", pod.Namespace, pod.Name, time.Now().UTC().Format("20060102-150405"))
+func (r *PodReconciler) launchCollector(ctx context.Context, pod *corev1.Pod, container string, checkpointPath string) {
+	key := r.capturePrefix(pod, time.Now()) + "/checkpoint.tar"
 
 	job := collector.BuildJob(collector.JobConfig{
 		Namespace:      r.Config.TargetNamespace,
 		NodeName:       pod.Spec.NodeName,
 		CheckpointPath: checkpointPath,
-		S3Bucket:       r.Config.S3Bucket,
-		S3Region:       r.Config.S3Region,
-		S3Key:          s3Key,
+		Storage:        r.Config.Storage,
+		Key:            key,
+		Metadata:       r.artifactMetadata(pod, container, nil, "", ""),
 		Image:          r.Config.Image,
 		OwnerReference: metav1.OwnerReference{
 			APIVersion: "v1",
@@ -373,14 +700,16 @@ func (r *PodReconciler) ensureNetworkPolicy(ctx context.Context) error {
 	return nil
 }
 
-// getPodLogs fetches logs from the crashed container
-func (r *PodReconciler) getPodLogs(ctx context.Context, pod *corev1.Pod, containerName string) (string, error) {
+// getPodLogs fetches logs from a container, either from its current instance
+// or from the instance that terminated before the last restart
+func (r *PodReconciler) getPodLogs(ctx context.Context, cfg ForensicsConfig, pod *corev1.Pod, containerName string, previous bool) (string, error) {
 	if containerName == "" {
 		return "", fmt.Errorf("no container name specified")
 	}
 
 	logOpts := &corev1.PodLogOptions{
 		Container: containerName,
+		Previous:  previous,
 	}
 
 	req := r.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOpts)
@@ -390,51 +719,35 @@ func (r *PodReconciler) getPodLogs(ctx context.Context, pod *corev1.Pod, contain
 	}
 	defer stream.Close()
 
+	// Keep the start and the end of the log (where the stack trace usually is)
+	if cfg.LogTruncationMode == LogTruncationHeadTail {
+		head := cfg.LogHeadBytes
+		if head > cfg.MaxLogSizeBytes {
+			head = cfg.MaxLogSizeBytes
+		}
+		return readHeadTail(stream, head, cfg.MaxLogSizeBytes-head)
+	}
+
 	// Limit reader to configured size
-	buf := make([]byte, r.Config.MaxLogSizeBytes)
+	buf := make([]byte, cfg.MaxLogSizeBytes)
 	n, err := io.ReadFull(stream, buf)
 	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		return "", err
 	}
 
 	logs := string(buf[:n])
-	if int64(n) == r.Config.MaxLogSizeBytes {
-		logs += fmt.Sprintf("\n... [TRUNCATED %d KB] ...", r.Config.MaxLogSizeBytes/1024)
+	if int64(n) == cfg.MaxLogSizeBytes {
+		logs += fmt.Sprintf("\n... [TRUNCATED %d KB] ...", cfg.MaxLogSizeBytes/1024)
 	}
 	return logs, nil
 }
 
-func (r *PodReconciler) createLogConfigMap(ctx context.Context, pod *corev1.Pod, logs string) (string, error) {
-	name := fmt.Sprintf("%s-logs", pod.Name)
-
-	cm := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: r.Config.TargetNamespace,
-			Labels: map[string]string{
-				LabelSourcePodUID: string(pod.UID),
-			},
-		},
-		Data: map[string]string{
-			LogConfigMapKey: logs,
-		},
-	}
-
-	cm.GenerateName = fmt.Sprintf("%s-logs-", pod.Name)
-	cm.Name = ""
-
-	if err := r.Create(ctx, cm); err != nil {
-		return "", err
-	}
-	return cm.Name, nil
-}
-
-func (r *PodReconciler) cloneDependencies(ctx context.Context, pod *corev1.Pod) (map[string]string, error) {
+func (r *PodReconciler) cloneDependencies(ctx context.Context, cfg ForensicsConfig, pod *corev1.Pod) (map[string]string, error) {
 	logger := log.FromContext(ctx)
 	resourceMap := make(map[string]string)
 
 	// Check Secret Cloning Opt-Out
-	secretsDisabled := !r.Config.EnableSecretCloning
+	secretsDisabled := !cfg.EnableSecretCloning
 	if pod.Annotations[AnnotationNoSecretClone] == "true" {
 		secretsDisabled = true
 	}
@@ -524,77 +837,17 @@ func (r *PodReconciler) cloneDependencies(ctx context.Context, pod *corev1.Pod)
 		return nil
 	}
 
-	// 1. Scan Volumes
-	for _, vol := range pod.Spec.Volumes {
-		if vol.ConfigMap != nil {
-			if err := handleConfigMap(vol.ConfigMap.Name); err != nil {
-				return nil, err
-			}
-		}
-		if vol.Secret != nil {
-			if err := handleSecret(vol.Secret.SecretName); err != nil {
-				return nil, err
-			}
-		}
-		if vol.Projected != nil {
-			for _, source := range vol.Projected.Sources {
-				if source.ConfigMap != nil {
-					if err := handleConfigMap(source.ConfigMap.Name); err != nil {
-						return nil, err
-					}
-				}
-				if source.Secret != nil {
-					if err := handleSecret(source.Secret.Name); err != nil {
-						return nil, err
-					}
-				}
-			}
-		}
-	}
-
-	// 2. Scan Containers for EnvFrom and Env
-	scanContainers := func(containers []corev1.Container) error {
-		for _, c := range containers {
-			for _, envFrom := range c.EnvFrom {
-				if envFrom.ConfigMapRef != nil {
-					if err := handleConfigMap(envFrom.ConfigMapRef.Name); err != nil {
-						return err
-					}
-				}
-				if envFrom.SecretRef != nil {
-					if err := handleSecret(envFrom.SecretRef.Name); err != nil {
-						return err
-					}
-				}
-			}
-			for _, env := range c.Env {
-				if env.ValueFrom != nil {
-					if env.ValueFrom.ConfigMapKeyRef != nil {
-						if err := handleConfigMap(env.ValueFrom.ConfigMapKeyRef.Name); err != nil {
-							return err
-						}
-					}
-					if env.ValueFrom.SecretKeyRef != nil {
-						if err := handleSecret(env.ValueFrom.SecretKeyRef.Name); err != nil {
-							return err
-						}
-					}
-				}
-			}
+	// Volumes, projected sources, envFrom, env and image pull secrets.
+	// PVCs are snapshotted instead.
+	for _, ref := range podReferences(pod) {
+		var err error
+		switch ref.Kind {
+		case refConfigMap:
+			err = handleConfigMap(ref.Name)
+		case refSecret:
+			err = handleSecret(ref.Name)
 		}
-		return nil
-	}
-
-	if err := scanContainers(pod.Spec.Containers); err != nil {
-		return nil, err
-	}
-	if err := scanContainers(pod.Spec.InitContainers); err != nil {
-		return nil, err
-	}
-
-	// 3. Scan ImagePullSecrets
-	for _, s := range pod.Spec.ImagePullSecrets {
-		if err := handleSecret(s.Name); err != nil {
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -602,7 +855,7 @@ func (r *PodReconciler) cloneDependencies(ctx context.Context, pod *corev1.Pod)
 	return resourceMap, nil
 }
 
-func (r *PodReconciler) createForensicPod(ctx context.Context, originalPod *corev1.Pod, resourceMap map[string]string, logCMName string, signature string, crashedContainerName string, exitCode int32, logHash string, snapshotMap map[string]string, checkpointLocation string, s3URL string) error {
+func (r *PodReconciler) createForensicPod(ctx context.Context, cfg ForensicsConfig, originalPod *corev1.Pod, resourceMap map[string]string, evidence *logEvidence, capturedLogs []capturedLog, logRead bool, signature string, exitSignature string, workload workloadIdentity, crashedContainerName string, exitCode int32, probableCause string, hold bool, logHash string, snapshotMap map[string]string, checkpointLocation string, s3URL string, exportPrefix string, caseName string, manifestCM string) (string, error) {
 	// Truncate original pod name for label
 	sourcePodName := originalPod.Name
 	if len(sourcePodName) > 63 {
@@ -611,7 +864,21 @@ func (r *PodReconciler) createForensicPod(ctx context.Context, originalPod *core
 
 	annotations := map[string]string{
 		"forensic.io/exit-code":  fmt.Sprintf("%d", exitCode),
+		AnnotationProbableCause:  probableCause,
 		"forensic.io/log-sha256": logHash,
+		AnnotationCrashLogKey:    capturedLogs[0].Key,
+		AnnotationStoragePrefix:  exportPrefix + "/",
+		AnnotationSourcePod:      originalPod.Namespace + "/" + originalPod.Name,
+	}
+
+	// Matched by the deduplication of the crashes of the container, see recentlyCaptured
+	if !logRead {
+		annotations[AnnotationLogUnavailable] = "true"
+	}
+
+	// Put on hold by a custom rule
+	if hold {
+		annotations[AnnotationForensicHold] = "true"
 	}
 
 	// Add Snapshot Info
@@ -633,6 +900,11 @@ func (r *PodReconciler) createForensicPod(ctx context.Context, originalPod *core
 		annotations[LabelLogS3URL] = s3URL
 	}
 
+	// Add Signed Manifest Info
+	if manifestCM != "" {
+		annotations[AnnotationManifest] = manifestCM
+	}
+
 	// Find original container to capture command/args
 	for _, c := range originalPod.Spec.Containers {
 		if c.Name == crashedContainerName {
@@ -668,13 +940,18 @@ func (r *PodReconciler) createForensicPod(ctx context.Context, originalPod *core
 				LabelSourcePod:      sourcePodName,
 				LabelSourcePodUID:   string(originalPod.UID),
 				LabelCrashSignature: signature,
+				LabelExitSignature:  exitSignature,
+				LabelWorkload:       workload.Label(),
 				LabelForensicTime:   time.Now().UTC().Format(ForensicTimeFormat),
-				LabelForensicTTL:    r.Config.ForensicTTL.String(),
+				LabelForensicTTL:    cfg.ForensicTTL.String(),
 			},
 			Annotations: annotations,
 		},
 		Spec: *originalPod.Spec.DeepCopy(),
 	}
+	if caseName != "" {
+		newPod.Labels[LabelForensicCase] = caseName
+	}
 
 	// Clean up spec for new pod
 	newPod.Spec.NodeName = "" // Let scheduler handle it
@@ -687,46 +964,49 @@ func (r *PodReconciler) createForensicPod(ctx context.Context, originalPod *core
 	newPod.Spec.ServiceAccountName = "default"
 	newPod.Spec.DeprecatedServiceAccount = "default"
 
-	// Feature 1: Mount Log ConfigMap
-	logVolName := "forensic-logs"
-	newPod.Spec.Volumes = append(newPod.Spec.Volumes, corev1.Volume{
-		Name: logVolName,
-		VolumeSource: corev1.VolumeSource{
-			ConfigMap: &corev1.ConfigMapVolumeSource{
-				LocalObjectReference: corev1.LocalObjectReference{Name: logCMName},
-			},
-		},
-	})
+	// Feature 1: Mount Log Evidence
+	// Every log keeps its own file name; the crashed instance is also exposed as crash.log
+	logVolumes, logAssembler := evidence.volumes()
+	newPod.Spec.Volumes = append(newPod.Spec.Volumes, logVolumes...)
+	var injectedInits []corev1.Container
+	if logAssembler != nil {
+		injectedInits = append(injectedInits, *logAssembler)
+	}
 
-	// Feature 2: Toolkit Volume
+	// Feature 2: Toolkit Volume (can be disabled by policy)
 	toolsVolName := "toolbox"
-	newPod.Spec.Volumes = append(newPod.Spec.Volumes, corev1.Volume{
-		Name: toolsVolName,
-		VolumeSource: corev1.VolumeSource{
-			EmptyDir: &corev1.EmptyDirVolumeSource{},
-		},
-	})
+	if cfg.EnableToolkit {
+		newPod.Spec.Volumes = append(newPod.Spec.Volumes, corev1.Volume{
+			Name: toolsVolName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
 
-	// Feature 2: Init Container
-	initContainer := corev1.Container{
-		Name:    "install-toolkit",
-		Image:   "busybox:1.36-musl", // Use musl/static build to ensure compatibility across distros
-		Command: []string{"/bin/sh", "-c", "cp /bin/sh /bin/ls /bin/cat /tools/"},
-		VolumeMounts: []corev1.VolumeMount{
-			{
-				Name:      toolsVolName,
-				MountPath: "/tools",
+		// Feature 2: Init Container
+		initContainer := corev1.Container{
+			Name:    "install-toolkit",
+			Image:   helperImage,
+			Command: []string{"/bin/sh", "-c", "cp /bin/sh /bin/ls /bin/cat /tools/"},
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      toolsVolName,
+					MountPath: "/tools",
+				},
 			},
-		},
-		Resources: corev1.ResourceRequirements{
-			Limits: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse("100m"),
-				corev1.ResourceMemory: resource.MustParse("50Mi"),
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("50Mi"),
+				},
 			},
-		},
+		}
+		injectedInits = append(injectedInits, initContainer)
 	}
-	// Prepend to InitContainers
-	newPod.Spec.InitContainers = append([]corev1.Container{initContainer}, newPod.Spec.InitContainers...)
+
+	// Prepend our init containers so they run before the original ones
+	newPod.Spec.InitContainers = append(injectedInits, newPod.Spec.InitContainers...)
+	firstOriginalInit := len(injectedInits)
 
 	// Update References
 	// 1. Volumes
@@ -768,7 +1048,12 @@ func (r *PodReconciler) createForensicPod(ctx context.Context, originalPod *core
 	updateContainer := func(c *corev1.Container) {
 		// Override Command
 		// Update PATH in the command itself
-		c.Command = []string{"/usr/local/bin/toolkit/sh", "-c", "export PATH=$PATH:/usr/local/bin/toolkit; echo 'Forensic Mode Active. Run your app manually.'; sleep infinity"}
+		if cfg.EnableToolkit {
+			c.Command = []string{"/usr/local/bin/toolkit/sh", "-c", "export PATH=$PATH:/usr/local/bin/toolkit; echo 'Forensic Mode Active. Run your app manually.'; sleep infinity"}
+		} else {
+			// Without the toolkit we rely on the image shipping a shell
+			c.Command = []string{"/bin/sh", "-c", "echo 'Forensic Mode Active. Run your app manually.'; sleep infinity"}
+		}
 		c.Args = nil
 
 		// Remove Probes
@@ -779,15 +1064,17 @@ func (r *PodReconciler) createForensicPod(ctx context.Context, originalPod *core
 		// Feature 1: Mount Logs
 		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
 			Name:      logVolName,
-			MountPath: "/forensics/original-logs",
+			MountPath: logMountPath,
 			ReadOnly:  true,
 		})
 
 		// Feature 2: Mount Toolkit
-		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
-			Name:      toolsVolName,
-			MountPath: "/usr/local/bin/toolkit",
-		})
+		if cfg.EnableToolkit {
+			c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+				Name:      toolsVolName,
+				MountPath: "/usr/local/bin/toolkit",
+			})
+		}
 
 		// Security Hardening: Drop Dangerous Capabilities
 		if c.SecurityContext == nil {
@@ -838,8 +1125,8 @@ func (r *PodReconciler) createForensicPod(ctx context.Context, originalPod *core
 	}
 	// We do not modify init containers (except the one we added) to have logs/toolkit,
 	// unless necessary. But original init containers might need dependency fix.
-	// We skip the first one which is ours (index 0).
-	for i := 1; i < len(newPod.Spec.InitContainers); i++ {
+	// We skip the log assembler and toolkit installer, which are ours.
+	for i := firstOriginalInit; i < len(newPod.Spec.InitContainers); i++ {
 
 		c := &newPod.Spec.InitContainers[i]
 		for k, envFrom := range c.EnvFrom {
@@ -870,7 +1157,10 @@ func (r *PodReconciler) createForensicPod(ctx context.Context, originalPod *core
 		}
 	}
 
-	return r.Create(ctx, newPod)
+	if err := r.Create(ctx, newPod); err != nil {
+		return "", err
+	}
+	return newPod.Name, nil
 }
 
 // startTTLLoop runs a background loop to clean up old forensic pods
@@ -885,6 +1175,10 @@ func (r *PodReconciler) startTTLLoop(ctx context.Context) {
 			return
 		case <-ticker.C:
 			r.cleanupExpiredPods(ctx, logger)
+			r.pruneRestarts(ctx, logger)
+			if r.Config.ArtifactRetention > 0 {
+				r.cleanupExpiredArtifacts(ctx, logger)
+			}
 		}
 	}
 }
@@ -925,6 +1219,7 @@ func (r *PodReconciler) cleanupExpiredPods(ctx context.Context, logger logr.Logg
 				logger.Error(err, "Failed to delete expired pod", "pod", pod.Name)
 				continue
 			}
+			r.markCaseExpired(ctx, &pod)
 
 			// 2. Delete Dependencies (ConfigMaps, Secrets) with same source UID
 			// Note: This relies on LabelSourcePodUID being accurate on dependencies.
@@ -935,6 +1230,73 @@ func (r *PodReconciler) cleanupExpiredPods(ctx context.Context, logger logr.Logg
 	}
 }
 
+// pruneRestarts drops the restart history of deleted pods
+func (r *PodReconciler) pruneRestarts(ctx context.Context, logger logr.Logger) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods); err != nil {
+		logger.Error(err, "Failed to list pods for restart tracking")
+		return
+	}
+	alive := make(map[string]bool, len(pods.Items))
+	for _, pod := range pods.Items {
+		alive[string(pod.UID)] = true
+	}
+	r.restarts.prune(alive)
+}
+
+// cleanupExpiredArtifacts deletes exported artifacts older than the artifact
+// retention. The bucket may be shared, so only objects whose metadata marks
+// them as written by the controller or its collector are touched. Every
+// artifact of a source pod, including on-demand checkpoints, is kept while
+// one of its forensic pods is on hold.
+func (r *PodReconciler) cleanupExpiredArtifacts(ctx context.Context, logger logr.Logger) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(r.Config.TargetNamespace)); err != nil {
+		logger.Error(err, "Failed to list pods for artifact retention")
+		return
+	}
+	held := make(map[string]bool)
+	for _, pod := range pods.Items {
+		if uid := pod.Labels[LabelSourcePodUID]; uid != "" && pod.Annotations[AnnotationForensicHold] == "true" {
+			held[uid] = true
+		}
+	}
+
+	objects, err := r.Storage.List(ctx, "")
+	if err != nil {
+		logger.Error(err, "Failed to list exported artifacts")
+		return
+	}
+
+	cutoff := time.Now().Add(-r.Config.ArtifactRetention)
+	deleted := 0
+	for _, o := range objects {
+		if o.LastModified.After(cutoff) {
+			continue
+		}
+		// Listings carry no metadata, so only expired objects are looked up
+		info, err := r.Storage.Stat(ctx, o.Key)
+		if err != nil {
+			logger.Error(err, "Failed to read artifact metadata", "key", o.Key)
+			continue
+		}
+		if info.Metadata[storage.MetaControllerVersion] == "" || info.Metadata[storage.MetaPodUID] == "" {
+			continue // Not ours
+		}
+		if held[info.Metadata[storage.MetaPodUID]] {
+			continue
+		}
+		if err := r.Storage.Delete(ctx, o.Key); err != nil {
+			logger.Error(err, "Failed to delete expired artifact", "key", o.Key)
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		logger.Info("Deleted expired artifacts", "count", deleted, "retention", r.Config.ArtifactRetention.String())
+	}
+}
+
 func (r *PodReconciler) deleteDependencies(ctx context.Context, sourceUID string, logger logr.Logger) {
 	opts := []client.ListOption{
 		client.InNamespace(r.Config.TargetNamespace),
@@ -957,6 +1319,20 @@ func (r *PodReconciler) deleteDependencies(ctx context.Context, sourceUID string
 		}
 	}
 
+	// Evidence PVCs and their loader pods
+	var loaders corev1.PodList
+	if err := r.List(ctx, &loaders, client.InNamespace(r.Config.TargetNamespace), client.MatchingLabels{LabelSourcePodUID: sourceUID, LabelLogLoader: "true"}); err == nil {
+		for _, p := range loaders.Items {
+			r.Delete(ctx, &p)
+		}
+	}
+	var pvcs corev1.PersistentVolumeClaimList
+	if err := r.List(ctx, &pvcs, opts...); err == nil {
+		for _, pvc := range pvcs.Items {
+			r.Delete(ctx, &pvc)
+		}
+	}
+
 	// VolumeSnapshots (These are in the Source Namespace, so we search globally by UID)
 	// We need to be careful not to list ALL snapshots if we can avoid it, but with LabelSelector it is fine.
 	var snapshots snapshotv1.VolumeSnapshotList
@@ -971,7 +1347,7 @@ func (r *PodReconciler) deleteDependencies(ctx context.Context, sourceUID string
 	}
 }
 
-func (r *PodReconciler) snapshotPVCs(ctx context.Context, pod *corev1.Pod) (map[string]string, error) {
+func (r *PodReconciler) snapshotPVCs(ctx context.Context, cfg ForensicsConfig, pod *corev1.Pod) (map[string]string, error) {
 	snapshotMap := make(map[string]string)
 
 	for _, vol := range pod.Spec.Volumes {
@@ -985,7 +1361,7 @@ func (r *PodReconciler) snapshotPVCs(ctx context.Context, pod *corev1.Pod) (map[
 					Namespace:    pod.Namespace, // Snapshots must be in PVC namespace
 					Labels: map[string]string{
 						LabelSourcePodUID: string(pod.UID),
-						LabelForensicTTL:  r.Config.ForensicTTL.String(),
+						LabelForensicTTL:  cfg.ForensicTTL.String(),
 					},
 				},
 				Spec: snapshotv1.VolumeSnapshotSpec{
@@ -1009,18 +1385,59 @@ func (r *PodReconciler) snapshotPVCs(ctx context.Context, pod *corev1.Pod) (map[
 	return snapshotMap, nil
 }
 
-func (r *PodReconciler) getCrashSignature(pod *corev1.Pod, containerName string, exitCode int32) string {
-	// Try to identify the "Workload" name
-	workloadName := pod.GenerateName
-	if len(pod.OwnerReferences) > 0 {
-		workloadName = pod.OwnerReferences[0].Name
+// getCrashSignature identifies a crash for deduplication. When the crash log
+// holds a stack trace, its fingerprint stands in for the container and exit
+// code: the same bug in two containers shares one signature, while two
+// different exceptions with the same exit code get one each.
+func (r *PodReconciler) getCrashSignature(pod *corev1.Pod, workload string, containerName string, exitCode int32, stack *fingerprint.Fingerprint) string {
+	if stack != nil && stack.Hash != "" {
+		// Input: Namespace + WorkloadName + StackFingerprint
+		return signatureOf(fmt.Sprintf("%s-%s-%s", pod.Namespace, workload, stack.Hash))
 	}
-	if workloadName == "" {
-		workloadName = pod.Name // Fallback for standalone pods
+	// Input: Namespace + WorkloadName + ContainerName + ExitCode
+	return signatureOf(fmt.Sprintf("%s-%s-%s-%d", pod.Namespace, workload, containerName, exitCode))
+}
+
+// recentlyCaptured reports whether a forensic pod was created for a crash
+// within the rate limit window. Crashes are matched on their signature. Without
+// a crash log, the stack trace is unknown and the signature falls back to the
+// container and exit code, so a capture is also matched on that exit signature
+// when the log of either crash could not be read. Otherwise a failed read would
+// flip the signature of the same crash and capture it twice.
+func (r *PodReconciler) recentlyCaptured(ctx context.Context, window time.Duration, signature string, exitSignature string, logRead bool) (bool, error) {
+	var bySignature, byExit corev1.PodList
+	if err := r.List(ctx, &bySignature, client.InNamespace(r.Config.TargetNamespace), client.MatchingLabels{LabelCrashSignature: signature}); err != nil {
+		return false, err
+	}
+	if err := r.List(ctx, &byExit, client.InNamespace(r.Config.TargetNamespace), client.MatchingLabels{LabelExitSignature: exitSignature}); err != nil {
+		return false, err
 	}
 
-	// Input: Namespace + WorkloadName + ContainerName + ExitCode
-	input := fmt.Sprintf("%s-%s-%s-%d", pod.Namespace, workloadName, containerName, exitCode)
+	now := time.Now()
+	for _, fp := range bySignature.Items {
+		if now.Sub(fp.CreationTimestamp.Time) < window {
+			return true, nil
+		}
+	}
+	for _, fp := range byExit.Items {
+		if now.Sub(fp.CreationTimestamp.Time) < window && (!logRead || fp.Annotations[AnnotationLogUnavailable] == "true") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// apiReader reads straight from the API server, falling back to the cached
+// client when no reader is set
+func (r *PodReconciler) apiReader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+	return r.APIReader
+}
+
+// signatureOf hashes the input of a crash signature
+func signatureOf(input string) string {
 	hash := sha256.Sum256([]byte(input))
 	// Truncate to 63 chars to satisfy K8s label limit
 	return hex.EncodeToString(hash[:])[:63]
@@ -1065,6 +1482,9 @@ func (r *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 	r.KubeClient = kubeClient
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
 
 	// Start TTL Cleaner
 	// We use the manager's context (which is cancelled on stop)
@@ -1076,6 +1496,16 @@ func (r *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
+	// Load custom rules and reload them on every change of their ConfigMap
+	if r.Config.RulesConfigMap != "" {
+		if r.Rules == nil {
+			r.Rules = rules.NewEngine()
+		}
+		if err := mgr.Add(manager.RunnableFunc(r.watchRules)); err != nil {
+			return err
+		}
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Pod{}).
 		Complete(r)
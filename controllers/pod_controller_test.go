@@ -1,9 +1,39 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	restfake "k8s.io/client-go/rest/fake"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+	"kube-forensics-controller/pkg/fingerprint"
+	"kube-forensics-controller/pkg/storage"
 )
 
 func TestForensicTimeFormat(t *testing.T) {
@@ -32,3 +62,276 @@ func TestForensicTimeFormat(t *testing.T) {
 
 	t.Logf("Generated label value: %s", val)
 }
+
+func TestCrashSignatureFingerprint(t *testing.T) {
+	r := &PodReconciler{}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-5d4f-abcde", Namespace: "default"}}
+	npe := fingerprint.Extract("Exception in thread \"main\" java.lang.NullPointerException\n\tat com.example.Orders.load(Orders.java:12)")
+	otherNPE := fingerprint.Extract("Exception in thread \"main\" java.lang.NullPointerException\n\tat com.example.Users.find(Users.java:30)")
+
+	// One bug in two containers
+	if r.getCrashSignature(pod, "Deployment/web", "app", 1, npe) != r.getCrashSignature(pod, "Deployment/web", "worker", 1, npe) {
+		t.Error("the same stack trace in two containers got different signatures")
+	}
+	// Two bugs with the same exit code
+	if r.getCrashSignature(pod, "Deployment/web", "app", 1, npe) == r.getCrashSignature(pod, "Deployment/web", "app", 1, otherNPE) {
+		t.Error("different stack traces got the same signature")
+	}
+	// Without a stack trace, the container and exit code identify the crash
+	if r.getCrashSignature(pod, "Deployment/web", "app", 1, nil) == r.getCrashSignature(pod, "Deployment/web", "worker", 1, nil) {
+		t.Error("crashes of two containers without a stack trace got the same signature")
+	}
+}
+
+func TestCleanupExpiredArtifacts(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	provider, err := storage.NewFilesystemProvider(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	ours := func(uid string) storage.Metadata {
+		return storage.Metadata{storage.MetaPodUID: uid, storage.MetaControllerVersion: "v1"}
+	}
+	artifacts := []struct {
+		key  string
+		meta storage.Metadata
+		old  bool
+	}{
+		{"prod/web/2026/01/02/100405/app.current.log", ours("web-uid"), true},
+		{"prod/web/2026/03/04/100405/app.current.log", ours("web-uid"), false},
+		// Held through the source pod, also the on-demand checkpoint of another capture
+		{"prod/held/2026/01/02/100405/app.current.log", ours("held-uid"), true},
+		{"prod/held/2026/01/01/080000/checkpoint.tar", ours("held-uid"), true},
+		// Foreign objects in a shared bucket
+		{"backups/db-2025.sql.gz", nil, true},
+		{"prod/web/notes.txt", storage.Metadata{storage.MetaPodUID: "web-uid"}, true},
+	}
+	for _, a := range artifacts {
+		if _, err := provider.Upload(ctx, a.key, []byte("log"), a.meta); err != nil {
+			t.Fatal(err)
+		}
+		if a.old {
+			if err := os.Chtimes(filepath.Join(root, a.key), old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	held := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "held-forensic-abc",
+			Namespace:   "debug-forensics",
+			Labels:      map[string]string{LabelSourcePodUID: "held-uid"},
+			Annotations: map[string]string{AnnotationForensicHold: "true"},
+		},
+	}
+	r := &PodReconciler{
+		Client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(held).Build(),
+		Storage: provider,
+		Config:  ForensicsConfig{TargetNamespace: "debug-forensics", ArtifactRetention: 24 * time.Hour},
+	}
+	r.cleanupExpiredArtifacts(ctx, logr.Discard())
+
+	objects, err := provider.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	sort.Strings(keys)
+	want := []string{
+		"backups/db-2025.sql.gz",
+		"prod/held/2026/01/01/080000/checkpoint.tar",
+		"prod/held/2026/01/02/100405/app.current.log",
+		"prod/web/2026/03/04/100405/app.current.log",
+		"prod/web/notes.txt",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("remaining artifacts %v, want %v", keys, want)
+	}
+}
+
+// newCaptureReconciler runs whole captures against fake clients, without
+// export, snapshots or signing
+func newCaptureReconciler(kube kubernetes.Interface, objs ...client.Object) (*PodReconciler, client.Client, *record.FakeRecorder) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = forensicv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&forensicv1alpha1.ForensicCase{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			// The rate limit needs the creation time the API server would set
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				obj.SetCreationTimestamp(metav1.Now())
+				return c.Create(ctx, obj, opts...)
+			},
+		}).
+		Build()
+	recorder := record.NewFakeRecorder(100)
+	return &PodReconciler{
+		Client:     c,
+		Scheme:     scheme,
+		KubeClient: kube,
+		Recorder:   recorder,
+		Config: ForensicsConfig{
+			TargetNamespace:   "debug-forensics",
+			ForensicTTL:       time.Hour,
+			RateLimitWindow:   time.Hour,
+			MaxLogSizeBytes:   1024 * 1024,
+			LogTruncationMode: LogTruncationHead,
+		},
+	}, c, recorder
+}
+
+// reconcilePod reconciles a source pod and returns the forensic pods that exist afterwards
+func reconcilePod(t *testing.T, r *PodReconciler, c client.Client, pod *corev1.Pod) []corev1.Pod {
+	t.Helper()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	var pods corev1.PodList
+	if err := c.List(context.Background(), &pods, client.InNamespace(r.Config.TargetNamespace)); err != nil {
+		t.Fatal(err)
+	}
+	return pods.Items
+}
+
+// logClientset serves container logs from a function, which the fake clientset
+// cannot do: its logs are always "fake logs"
+type logClientset struct {
+	kubernetes.Interface
+	logs func(opts *corev1.PodLogOptions) (string, error)
+	// reads counts the log requests
+	reads int
+}
+
+func (c *logClientset) CoreV1() corev1client.CoreV1Interface {
+	return logCoreV1{c.Interface.CoreV1(), c}
+}
+
+type logCoreV1 struct {
+	corev1client.CoreV1Interface
+	c *logClientset
+}
+
+func (v logCoreV1) Pods(namespace string) corev1client.PodInterface {
+	return logPods{v.CoreV1Interface.Pods(namespace), v.c}
+}
+
+type logPods struct {
+	corev1client.PodInterface
+	c *logClientset
+}
+
+func (p logPods) GetLogs(name string, opts *corev1.PodLogOptions) *rest.Request {
+	rc := &restfake.RESTClient{
+		NegotiatedSerializer: clientgoscheme.Codecs.WithoutConversion(),
+		Client: restfake.CreateHTTPClient(func(*http.Request) (*http.Response, error) {
+			p.c.reads++
+			data, err := p.c.logs(opts)
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(data))}, nil
+		}),
+	}
+	return rc.Get().Resource("pods").Name(name).SubResource("log")
+}
+
+func TestReconcileLogFetchFailure(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-abcde", Namespace: "default", UID: "api-uid"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "api", Image: "api:1"}, {Name: "envoy", Image: "envoy:1"}}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "api", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 2, Reason: "Error"}}},
+				{Name: "envoy", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+	var logErr error
+	kube := &logClientset{Interface: kubefake.NewSimpleClientset(pod)}
+	kube.logs = func(opts *corev1.PodLogOptions) (string, error) {
+		if opts.Container == "api" && logErr != nil {
+			return "", logErr
+		}
+		return "panic: runtime error: invalid memory address\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:12 +0x25\n", nil
+	}
+	r, c, _ := newCaptureReconciler(kube, pod)
+
+	// The crash log cannot be read: the crash is captured without a stack trace
+	logErr = fmt.Errorf("connection reset by peer")
+	forensicPods := reconcilePod(t, r, c, pod)
+	if len(forensicPods) != 1 || forensicPods[0].Annotations[AnnotationLogUnavailable] != "true" {
+		t.Fatalf("got forensic pods %+v, want one captured without its log", forensicPods)
+	}
+
+	// The log can be read again and holds a stack trace, which changes the
+	// signature, but this is the same crash. Only the crash log is read.
+	logErr = nil
+	kube.reads = 0
+	if forensicPods := reconcilePod(t, r, c, pod); len(forensicPods) != 1 {
+		t.Errorf("got %d forensic pods once the log could be read, want 1", len(forensicPods))
+	}
+	if kube.reads != 1 {
+		t.Errorf("got %d log reads for a rate limited crash, want only the crash log", kube.reads)
+	}
+
+	// And failing again does not capture it either
+	logErr = fmt.Errorf("connection reset by peer")
+	if forensicPods := reconcilePod(t, r, c, pod); len(forensicPods) != 1 {
+		t.Errorf("got %d forensic pods after another failed read, want 1", len(forensicPods))
+	}
+}
+
+func TestRecentlyCaptured(t *testing.T) {
+	ctx := context.Background()
+	forensicPod := func(name, signature string, logUnavailable bool) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "debug-forensics", CreationTimestamp: metav1.Now(),
+			Labels:      map[string]string{LabelCrashSignature: signature, LabelExitSignature: "exit"},
+			Annotations: map[string]string{},
+		}}
+		if logUnavailable {
+			p.Annotations[AnnotationLogUnavailable] = "true"
+		}
+		return p
+	}
+
+	// A crash with a stack trace is not rate limited by another exception with the same exit code
+	r, _, _ := newCaptureReconciler(kubefake.NewSimpleClientset(), forensicPod("other", "stack-1", false))
+	for _, tc := range []struct {
+		signature string
+		logRead   bool
+		want      bool
+	}{
+		{"stack-1", true, true},
+		{"stack-2", true, false},
+		{"exit", false, true},
+	} {
+		got, err := r.recentlyCaptured(ctx, time.Hour, tc.signature, "exit", tc.logRead)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("signature %s (log read: %v): got %v, want %v", tc.signature, tc.logRead, got, tc.want)
+		}
+	}
+
+	// A capture without its log rate limits every crash with the same exit code
+	r, _, _ = newCaptureReconciler(kubefake.NewSimpleClientset(), forensicPod("unread", "exit", true))
+	if got, _ := r.recentlyCaptured(ctx, time.Hour, "stack-2", "exit", true); !got {
+		t.Error("crash not matched with the capture that had no log")
+	}
+}
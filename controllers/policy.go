@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+)
+
+// resolveConfig returns the capture settings for a pod: the global config with the
+// most specific matching ForensicPolicy applied on top. The returned name is empty
+// when no policy matched.
+func (r *PodReconciler) resolveConfig(ctx context.Context, pod *corev1.Pod) (ForensicsConfig, string, error) {
+	var policies forensicv1alpha1.ForensicPolicyList
+	if err := r.List(ctx, &policies); err != nil {
+		return r.Config, "", err
+	}
+	if len(policies.Items) == 0 {
+		return r.Config, "", nil
+	}
+
+	// Namespace labels are only needed by policies using a NamespaceSelector
+	var nsLabels map[string]string
+	for _, p := range policies.Items {
+		if len(p.Spec.Namespaces) == 0 && p.Spec.NamespaceSelector != nil {
+			var ns corev1.Namespace
+			if err := r.Get(ctx, types.NamespacedName{Name: pod.Namespace}, &ns); err != nil {
+				return r.Config, "", err
+			}
+			nsLabels = ns.Labels
+			break
+		}
+	}
+
+	policy := selectPolicy(policies.Items, pod, nsLabels)
+	if policy == nil {
+		return r.Config, "", nil
+	}
+	return applyPolicy(r.Config, policy), policy.Name, nil
+}
+
+// selectPolicy picks the most specific policy matching the pod.
+// Specificity, from most to least specific:
+//  1. Namespace listed by name (+4), or matched by NamespaceSelector (+2)
+//  2. Non-empty PodSelector (+1)
+//
+// Ties are broken by the number of selector requirements, then by name.
+func selectPolicy(policies []forensicv1alpha1.ForensicPolicy, pod *corev1.Pod, nsLabels map[string]string) *forensicv1alpha1.ForensicPolicy {
+	type candidate struct {
+		policy       *forensicv1alpha1.ForensicPolicy
+		score        int
+		requirements int
+	}
+
+	var candidates []candidate
+	for i := range policies {
+		p := &policies[i]
+		score, requirements, ok := matchPolicy(p, pod, nsLabels)
+		if ok {
+			candidates = append(candidates, candidate{policy: p, score: score, requirements: requirements})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		if candidates[i].requirements != candidates[j].requirements {
+			return candidates[i].requirements > candidates[j].requirements
+		}
+		return candidates[i].policy.Name < candidates[j].policy.Name
+	})
+	return candidates[0].policy
+}
+
+// matchPolicy reports whether the policy selects the pod, and how specifically
+func matchPolicy(p *forensicv1alpha1.ForensicPolicy, pod *corev1.Pod, nsLabels map[string]string) (int, int, bool) {
+	score := 0
+	requirements := 0
+
+	switch {
+	case len(p.Spec.Namespaces) > 0:
+		found := false
+		for _, ns := range p.Spec.Namespaces {
+			if ns == pod.Namespace {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, 0, false
+		}
+		score += 4
+	case p.Spec.NamespaceSelector != nil:
+		selector, err := metav1.LabelSelectorAsSelector(p.Spec.NamespaceSelector)
+		if err != nil || !selector.Matches(labels.Set(nsLabels)) {
+			return 0, 0, false
+		}
+		if !selector.Empty() {
+			score += 2
+			requirements += selectorRequirements(p.Spec.NamespaceSelector)
+		}
+	}
+
+	if p.Spec.PodSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(p.Spec.PodSelector)
+		if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			return 0, 0, false
+		}
+		if !selector.Empty() {
+			score++
+			requirements += selectorRequirements(p.Spec.PodSelector)
+		}
+	}
+
+	return score, requirements, true
+}
+
+func selectorRequirements(s *metav1.LabelSelector) int {
+	return len(s.MatchLabels) + len(s.MatchExpressions)
+}
+
+// applyPolicy overlays the fields set on a policy onto a copy of the config
+func applyPolicy(cfg ForensicsConfig, p *forensicv1alpha1.ForensicPolicy) ForensicsConfig {
+	if p.Spec.ForensicTTL != nil {
+		cfg.ForensicTTL = p.Spec.ForensicTTL.Duration
+	}
+	if p.Spec.MaxLogSizeBytes != nil {
+		cfg.MaxLogSizeBytes = *p.Spec.MaxLogSizeBytes
+	}
+	if p.Spec.EnableSecretCloning != nil {
+		cfg.EnableSecretCloning = *p.Spec.EnableSecretCloning
+	}
+	if p.Spec.RateLimitWindow != nil {
+		cfg.RateLimitWindow = p.Spec.RateLimitWindow.Duration
+	}
+	if p.Spec.EnableSnapshots != nil {
+		cfg.EnableSnapshots = *p.Spec.EnableSnapshots
+	}
+	if p.Spec.EnableExport != nil {
+		cfg.EnableExport = *p.Spec.EnableExport
+	}
+	if p.Spec.EnableToolkit != nil {
+		cfg.EnableToolkit = *p.Spec.EnableToolkit
+	}
+	return cfg
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+)
+
+func TestSelectPolicyPrefersMostSpecific(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "checkout-1",
+			Namespace: "payments",
+			Labels:    map[string]string{"app": "checkout"},
+		},
+	}
+	nsLabels := map[string]string{"team": "payments"}
+
+	policy := func(name string, spec forensicv1alpha1.ForensicPolicySpec) forensicv1alpha1.ForensicPolicy {
+		return forensicv1alpha1.ForensicPolicy{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+	}
+	catchAll := policy("catch-all", forensicv1alpha1.ForensicPolicySpec{})
+	bySelector := policy("by-ns-label", forensicv1alpha1.ForensicPolicySpec{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+	})
+	byName := policy("by-ns-name", forensicv1alpha1.ForensicPolicySpec{Namespaces: []string{"payments"}})
+	byNameAndPod := policy("by-ns-name-and-pod", forensicv1alpha1.ForensicPolicySpec{
+		Namespaces:  []string{"payments"},
+		PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "checkout"}},
+	})
+	otherNamespace := policy("other-ns", forensicv1alpha1.ForensicPolicySpec{
+		Namespaces:  []string{"batch"},
+		PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "checkout"}},
+	})
+
+	tests := []struct {
+		name     string
+		policies []forensicv1alpha1.ForensicPolicy
+		want     string
+	}{
+		{"no match", []forensicv1alpha1.ForensicPolicy{otherNamespace}, ""},
+		{"catch-all", []forensicv1alpha1.ForensicPolicy{catchAll, otherNamespace}, "catch-all"},
+		{"selector beats catch-all", []forensicv1alpha1.ForensicPolicy{catchAll, bySelector}, "by-ns-label"},
+		{"name beats selector", []forensicv1alpha1.ForensicPolicy{bySelector, byName, catchAll}, "by-ns-name"},
+		{"pod selector adds specificity", []forensicv1alpha1.ForensicPolicy{byName, byNameAndPod, bySelector}, "by-ns-name-and-pod"},
+	}
+
+	for _, tt := range tests {
+		got := selectPolicy(tt.policies, pod, nsLabels)
+		gotName := ""
+		if got != nil {
+			gotName = got.Name
+		}
+		if gotName != tt.want {
+			t.Errorf("%s: selected %q, want %q", tt.name, gotName, tt.want)
+		}
+	}
+}
+
+func TestApplyPolicyOnlyOverridesSetFields(t *testing.T) {
+	global := ForensicsConfig{
+		ForensicTTL:         24 * time.Hour,
+		MaxLogSizeBytes:     1024,
+		EnableSecretCloning: true,
+		RateLimitWindow:     time.Hour,
+		EnableSnapshots:     true,
+		EnableExport:        true,
+		EnableToolkit:       true,
+	}
+	disabled := false
+	p := &forensicv1alpha1.ForensicPolicy{
+		Spec: forensicv1alpha1.ForensicPolicySpec{
+			ForensicTTL:         &metav1.Duration{Duration: 4 * time.Hour},
+			EnableSecretCloning: &disabled,
+		},
+	}
+
+	got := applyPolicy(global, p)
+	if got.ForensicTTL != 4*time.Hour || got.EnableSecretCloning {
+		t.Errorf("Policy overrides not applied: %+v", got)
+	}
+	if got.MaxLogSizeBytes != 1024 || got.RateLimitWindow != time.Hour || !got.EnableSnapshots || !got.EnableExport || !got.EnableToolkit {
+		t.Errorf("Unset policy fields changed the global config: %+v", got)
+	}
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+	"kube-forensics-controller/pkg/rootcause"
+)
+
+// AnnotationProbableCause holds the probable cause category on forensic pods
+const AnnotationProbableCause = "forensic.io/probable-cause"
+
+// probableCause classifies the crashed instance of a container from its exit
+// code, its termination reason and message, and its log. The termination
+// reason of the instance is preferred over the criterion that caught the crash
+// (e.g. CrashLoopBackOff), except for evictions, which have none.
+func probableCause(pod *corev1.Pod, container string, instance string, reason string, exitCode int32, log string) rootcause.Cause {
+	in := rootcause.Input{ExitCode: exitCode, Reason: reason, Log: log}
+	if status := findContainerStatus(pod, container); status != nil && reason != ReasonEvicted {
+		terminated := status.State.Terminated
+		if instance == LogInstancePrevious {
+			terminated = status.LastTerminationState.Terminated
+		}
+		if terminated != nil {
+			in.Reason, in.Message = terminated.Reason, terminated.Message
+		}
+	}
+	return rootcause.Classify(in)
+}
+
+// caseProbableCause records a probable cause on a ForensicCase
+func caseProbableCause(c rootcause.Cause) *forensicv1alpha1.ProbableCause {
+	return &forensicv1alpha1.ProbableCause{
+		Category:    c.Category,
+		Signal:      c.Signal,
+		Description: c.Description,
+		Evidence:    c.Evidence,
+	}
+}
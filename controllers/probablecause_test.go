@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"kube-forensics-controller/pkg/rootcause"
+)
+
+func TestProbableCause(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		Name:  "app",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 137, Reason: "OOMKilled",
+		}},
+	}}}}
+
+	// Caught by the restart loop criterion: the instance was OOM killed
+	if c := probableCause(pod, "app", LogInstancePrevious, "CrashLoopBackOff", 137, ""); c.Category != rootcause.CategoryOOMKilled {
+		t.Errorf("got %s, want the termination reason of the instance", c.Category)
+	}
+
+	// Evicted before any container terminated
+	if c := probableCause(evictedPod(), "redis", LogInstanceCurrent, ReasonEvicted, 0, ""); c.Category != rootcause.CategoryEvicted {
+		t.Errorf("got %s, want Evicted", c.Category)
+	}
+
+	pod.Status.ContainerStatuses[0].LastTerminationState.Terminated.Reason = "Error"
+	c := probableCause(pod, "app", LogInstancePrevious, "CrashLoopBackOff", 137, "java.lang.OutOfMemoryError: Java heap space")
+	if c.Category != rootcause.CategoryJVMOutOfMemory || c.Signal != "SIGKILL" {
+		t.Errorf("got (%s, %s), want the JVM heap exhaustion from the log", c.Category, c.Signal)
+	}
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Kinds of objects a pod refers to
+const (
+	refConfigMap = "configmap"
+	refSecret    = "secret"
+	refPVC       = "pvc"
+)
+
+// podReference is a ConfigMap, Secret or PVC used by a pod
+type podReference struct {
+	Kind     string
+	Name     string
+	Key      string // Set for env entries that read a single key
+	Optional bool
+}
+
+func (ref podReference) String() string {
+	if ref.Key != "" {
+		return fmt.Sprintf("%s/%s:%s", ref.Kind, ref.Name, ref.Key)
+	}
+	return fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+}
+
+// podReferences lists the objects a pod refers to: volumes, projected
+// sources, envFrom and env of all containers, then image pull secrets.
+// An object is listed once per way it is used.
+func podReferences(pod *corev1.Pod) []podReference {
+	var refs []podReference
+	optional := func(o *bool) bool { return o != nil && *o }
+
+	// 1. Volumes
+	for _, vol := range pod.Spec.Volumes {
+		if vol.ConfigMap != nil {
+			refs = append(refs, podReference{Kind: refConfigMap, Name: vol.ConfigMap.Name, Optional: optional(vol.ConfigMap.Optional)})
+		}
+		if vol.Secret != nil {
+			refs = append(refs, podReference{Kind: refSecret, Name: vol.Secret.SecretName, Optional: optional(vol.Secret.Optional)})
+		}
+		if vol.PersistentVolumeClaim != nil {
+			refs = append(refs, podReference{Kind: refPVC, Name: vol.PersistentVolumeClaim.ClaimName})
+		}
+		if vol.Projected != nil {
+			for _, source := range vol.Projected.Sources {
+				if source.ConfigMap != nil {
+					refs = append(refs, podReference{Kind: refConfigMap, Name: source.ConfigMap.Name, Optional: optional(source.ConfigMap.Optional)})
+				}
+				if source.Secret != nil {
+					refs = append(refs, podReference{Kind: refSecret, Name: source.Secret.Name, Optional: optional(source.Secret.Optional)})
+				}
+			}
+		}
+	}
+
+	// 2. EnvFrom and Env
+	for _, c := range containersOf(pod) {
+		for _, envFrom := range c.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				refs = append(refs, podReference{Kind: refConfigMap, Name: envFrom.ConfigMapRef.Name, Optional: optional(envFrom.ConfigMapRef.Optional)})
+			}
+			if envFrom.SecretRef != nil {
+				refs = append(refs, podReference{Kind: refSecret, Name: envFrom.SecretRef.Name, Optional: optional(envFrom.SecretRef.Optional)})
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				refs = append(refs, podReference{Kind: refConfigMap, Name: ref.Name, Key: ref.Key, Optional: optional(ref.Optional)})
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				refs = append(refs, podReference{Kind: refSecret, Name: ref.Name, Key: ref.Key, Optional: optional(ref.Optional)})
+			}
+		}
+	}
+
+	// 3. ImagePullSecrets, which the kubelet tolerates missing
+	for _, s := range pod.Spec.ImagePullSecrets {
+		refs = append(refs, podReference{Kind: refSecret, Name: s.Name, Optional: true})
+	}
+	return refs
+}
+
+// containersOf returns the regular and init containers of a pod
+func containersOf(pod *corev1.Pod) []corev1.Container {
+	containers := make([]corev1.Container, 0, len(pod.Spec.Containers)+len(pod.Spec.InitContainers))
+	containers = append(containers, pod.Spec.Containers...)
+	return append(containers, pod.Spec.InitContainers...)
+}
+
+// missingReferences lists the objects and keys a pod needs that do not exist
+// in its namespace, e.g. configmap/app-config or configmap/app-config:LOG_LEVEL.
+// Optional references are skipped. Reads go straight to the API server so
+// that no informer is started for PVCs.
+func (r *PodReconciler) missingReferences(ctx context.Context, pod *corev1.Pod) ([]string, error) {
+	reader := r.apiReader()
+
+	var missing []string
+	reported := make(map[string]bool)
+	objects := make(map[string]client.Object) // nil for objects that do not exist
+	for _, ref := range podReferences(pod) {
+		if ref.Optional {
+			continue
+		}
+		objKey := podReference{Kind: ref.Kind, Name: ref.Name}.String()
+		obj, fetched := objects[objKey]
+		if !fetched {
+			switch ref.Kind {
+			case refConfigMap:
+				obj = &corev1.ConfigMap{}
+			case refSecret:
+				obj = &corev1.Secret{}
+			default:
+				obj = &corev1.PersistentVolumeClaim{}
+			}
+			err := reader.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: pod.Namespace}, obj)
+			if errors.IsNotFound(err) {
+				obj = nil
+			} else if err != nil {
+				return missing, fmt.Errorf("%s: %w", objKey, err)
+			}
+			objects[objKey] = obj
+		}
+
+		item := ""
+		switch {
+		case obj == nil:
+			item = objKey
+		case ref.Key != "" && !hasKey(obj, ref.Key):
+			item = ref.String()
+		}
+		if item != "" && !reported[item] {
+			reported[item] = true
+			missing = append(missing, item)
+		}
+	}
+	return missing, nil
+}
+
+// hasKey reports whether a ConfigMap or Secret holds a key
+func hasKey(obj client.Object, key string) bool {
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		_, inData := o.Data[key]
+		_, inBinary := o.BinaryData[key]
+		return inData || inBinary
+	case *corev1.Secret:
+		_, ok := o.Data[key]
+		return ok
+	}
+	return false
+}
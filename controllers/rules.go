@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+	"kube-forensics-controller/pkg/rules"
+)
+
+// watchRules keeps the custom rules in sync with the rules ConfigMap until the
+// context is cancelled. Only that ConfigMap is watched, so the controller does
+// not cache the ConfigMaps of the cluster. An invalid update keeps the rules
+// in place; deleting the ConfigMap removes them.
+func (r *PodReconciler) watchRules(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithValues("configmap", r.Config.RulesNamespace+"/"+r.Config.RulesConfigMap)
+	configMaps := r.KubeClient.CoreV1().ConfigMaps(r.Config.RulesNamespace)
+	selector := fields.OneTermEqualSelector("metadata.name", r.Config.RulesConfigMap).String()
+	informer := cache.NewSharedInformer(&cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = selector
+			return configMaps.List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = selector
+			return configMaps.Watch(ctx, opts)
+		},
+	}, &corev1.ConfigMap{}, 0)
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			r.loadRules(ctx, obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			r.loadRules(ctx, obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if cm, ok := obj.(*corev1.ConfigMap); !ok || cm.Name != r.Config.RulesConfigMap {
+				return
+			}
+			_, _ = r.Rules.Load(nil)
+			logger.Info("Rules ConfigMap deleted, custom rules removed")
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	informer.Run(ctx.Done())
+	return nil
+}
+
+// loadRules replaces the custom rules with those of the rules ConfigMap, and
+// reports the outcome as an event on the ConfigMap
+func (r *PodReconciler) loadRules(ctx context.Context, obj interface{}) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm.Name != r.Config.RulesConfigMap {
+		return
+	}
+
+	logger := log.FromContext(ctx).WithValues("configmap", cm.Namespace+"/"+cm.Name)
+	n, err := r.Rules.Load(cm.Data)
+	if err != nil {
+		logger.Error(err, "Invalid custom rules (keeping the current rules)")
+		r.Recorder.Eventf(cm, corev1.EventTypeWarning, "ForensicRulesInvalid", "Invalid custom rules, keeping the current rules: %v", err)
+		return
+	}
+	logger.Info("Loaded custom rules", "rules", n)
+	r.Recorder.Eventf(cm, corev1.EventTypeNormal, "ForensicRulesLoaded", "Loaded %d custom rules", n)
+}
+
+// AnnotationCaptureSkipped names the case of the last crash of a source pod a
+// custom rule skipped. The crash has no forensic pod to rate limit it, so this
+// keeps later reconciles from reporting it again.
+const AnnotationCaptureSkipped = "forensic.io/capture-skipped"
+
+// ruleActions reports whether a matched rule puts the forensic pod on hold, and
+// whether one skips the capture
+func ruleActions(matches []rules.Match) (hold bool, skip bool) {
+	for _, m := range matches {
+		hold = hold || m.Rule.HasAction(rules.ActionHold)
+		skip = skip || m.Rule.HasAction(rules.ActionSkipCapture)
+	}
+	return hold, skip
+}
+
+// captureSkipped reports whether the crash of a container was already skipped
+// by a custom rule
+func captureSkipped(pod *corev1.Pod, container string) bool {
+	return pod.Annotations[AnnotationCaptureSkipped] == forensicCaseName(pod, container)
+}
+
+// markCaptureSkipped records on the source pod that a custom rule skipped the
+// capture of the crash of a container
+func (r *PodReconciler) markCaptureSkipped(ctx context.Context, pod *corev1.Pod, container string) error {
+	patch := client.MergeFrom(pod.DeepCopy())
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[AnnotationCaptureSkipped] = forensicCaseName(pod, container)
+	return r.Patch(ctx, pod, patch)
+}
+
+// applyRules records the custom rules a crash log matched on the case and as
+// events on the source pod, and raises the pages they ask for. A match the
+// case already holds, from an earlier attempt at the same capture, is neither
+// recorded nor reported again.
+func (r *PodReconciler) applyRules(pod *corev1.Pod, fcase *forensicv1alpha1.ForensicCase, matches []rules.Match) {
+	var skippedBy []string
+	for _, m := range matches {
+		rule := m.Rule
+		if rule.HasAction(rules.ActionSkipCapture) {
+			skippedBy = append(skippedBy, rule.Name)
+		}
+		if fcase != nil {
+			if hasRuleMatch(fcase, rule.Name, m.Line) {
+				continue
+			}
+			fcase.Status.RuleMatches = append(fcase.Status.RuleMatches, forensicv1alpha1.RuleMatch{
+				Rule:        rule.Name,
+				Severity:    rule.Severity,
+				Category:    rule.Category,
+				Remediation: rule.Remediation,
+				Actions:     rule.Actions,
+				Line:        m.Line,
+			})
+		}
+
+		eventType := corev1.EventTypeWarning
+		if rule.Severity == rules.SeverityInfo {
+			eventType = corev1.EventTypeNormal
+		}
+		msg := fmt.Sprintf(`Rule %s (%s) matched: "%s"`, rule.Name, rule.Severity, m.Line)
+		if rule.Remediation != "" {
+			msg += ". Remediation: " + rule.Remediation
+		}
+		r.Recorder.Event(pod, eventType, "ForensicRuleMatched", msg)
+		ForensicRuleMatchesTotal.WithLabelValues(pod.Namespace, rule.Name, rule.Severity).Inc()
+
+		if rule.HasAction(rules.ActionPage) {
+			r.Recorder.Eventf(pod, corev1.EventTypeWarning, "ForensicPage", "Page raised by rule %s: %s", rule.Name, m.Line)
+			ForensicRulePagesTotal.WithLabelValues(pod.Namespace, rule.Name).Inc()
+		}
+	}
+
+	if len(skippedBy) > 0 {
+		r.Recorder.Eventf(pod, corev1.EventTypeNormal, "ForensicCaptureSkipped", "Capture skipped by rule %s", strings.Join(skippedBy, ", "))
+	}
+}
+
+// hasRuleMatch reports whether a case holds the match of a rule on a line
+func hasRuleMatch(fcase *forensicv1alpha1.ForensicCase, rule string, line string) bool {
+	for _, m := range fcase.Status.RuleMatches {
+		if m.Rule == rule && m.Line == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+	"kube-forensics-controller/pkg/rules"
+)
+
+const credentialRule = `rules:
+- name: db-credentials-rotated
+  substring: "FATAL: password authentication failed"
+  severity: critical
+  remediation: Restart the workload
+  actions: [hold, page]
+`
+
+func TestWatchRulesReloads(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kube := kubefake.NewSimpleClientset()
+	r := &PodReconciler{
+		KubeClient: kube,
+		Recorder:   record.NewFakeRecorder(10),
+		Rules:      rules.NewEngine(),
+		Config:     ForensicsConfig{RulesConfigMap: "forensic-rules", RulesNamespace: "forensics"},
+	}
+	go func() { _ = r.watchRules(ctx) }()
+
+	waitForRules := func(want int) {
+		t.Helper()
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+			return r.Rules.Len() == want, nil
+		})
+		if err != nil {
+			t.Fatalf("got %d rules, want %d", r.Rules.Len(), want)
+		}
+	}
+
+	configMaps := kube.CoreV1().ConfigMaps("forensics")
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "forensic-rules", Namespace: "forensics"},
+		Data:       map[string]string{"db.yaml": credentialRule},
+	}
+	if _, err := configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRules(1)
+
+	cm.Data["flags.yaml"] = "rules:\n- name: flags\n  substring: flagd\n"
+	if _, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRules(2)
+
+	if err := configMaps.Delete(ctx, cm.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRules(0)
+}
+
+func TestApplyRules(t *testing.T) {
+	engine := rules.NewEngine()
+	_, err := engine.Load(map[string]string{
+		"db.yaml":    credentialRule,
+		"flags.yaml": "rules:\n- name: flags-unavailable\n  substring: flagd\n  severity: info\n  actions: [skip-capture]\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "shop"}}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &PodReconciler{Recorder: recorder, Rules: engine}
+	fcase := &forensicv1alpha1.ForensicCase{}
+	matches := engine.Match(`FATAL: password authentication failed for user "orders"`)
+	if hold, skip := ruleActions(matches); !hold || skip {
+		t.Errorf("got hold=%v skip=%v, want a hold", hold, skip)
+	}
+	r.applyRules(pod, fcase, matches)
+	if len(fcase.Status.RuleMatches) != 1 || fcase.Status.RuleMatches[0].Remediation != "Restart the workload" {
+		t.Errorf("got %+v", fcase.Status.RuleMatches)
+	}
+	if e := <-recorder.Events; !strings.HasPrefix(e, "Warning ForensicRuleMatched Rule db-credentials-rotated (critical)") {
+		t.Errorf("got event %q", e)
+	}
+	if e := <-recorder.Events; !strings.HasPrefix(e, "Warning ForensicPage") {
+		t.Errorf("got event %q", e)
+	}
+
+	// A retried capture does not record or page the same match twice
+	r.applyRules(pod, fcase, matches)
+	if len(fcase.Status.RuleMatches) != 1 {
+		t.Errorf("got %d matches after a retry, want 1", len(fcase.Status.RuleMatches))
+	}
+	if n := len(recorder.Events); n != 0 {
+		t.Errorf("got %d events after a retry, want none", n)
+	}
+
+	// A skipped crash reports the skip
+	matches = engine.Match("flagd: connection refused")
+	if _, skip := ruleActions(matches); !skip {
+		t.Fatal("capture not skipped")
+	}
+	r.applyRules(pod, nil, matches)
+	if n := len(recorder.Events); n != 2 {
+		t.Errorf("got %d events, want a match and a skip", n)
+	}
+
+	// Without rules nothing happens
+	r.Rules = nil
+	if hold, skip := ruleActions(r.Rules.Match("FATAL: password authentication failed")); hold || skip {
+		t.Error("actions without rules")
+	}
+}
+
+func TestReconcileSkippedCapture(t *testing.T) {
+	engine := rules.NewEngine()
+	_, err := engine.Load(map[string]string{
+		"flags.yaml": "rules:\n- name: flags-unavailable\n  substring: flagd\n  actions: [skip-capture, page]\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a case, the skip is still reported once
+	for _, caseFails := range []bool{false, true} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout-abcde", Namespace: "shop", UID: "checkout-uid"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "checkout", Image: "checkout:1"}}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "checkout",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}},
+				}},
+			},
+		}
+		kube := &logClientset{Interface: kubefake.NewSimpleClientset(pod), logs: func(*corev1.PodLogOptions) (string, error) {
+			return "flagd: connection refused\n", nil
+		}}
+		r, c, recorder := newCaptureReconciler(kube, pod)
+		r.Rules = engine
+		if caseFails {
+			r.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					if _, ok := obj.(*forensicv1alpha1.ForensicCase); ok {
+						return fmt.Errorf("admission webhook unavailable")
+					}
+					return c.Create(ctx, obj, opts...)
+				},
+			})
+		}
+
+		for i := 0; i < 2; i++ {
+			if forensicPods := reconcilePod(t, r, c, pod); len(forensicPods) != 0 {
+				t.Fatalf("got %d forensic pods for a skipped capture", len(forensicPods))
+			}
+		}
+
+		var pages, skips int
+		for len(recorder.Events) > 0 {
+			e := <-recorder.Events
+			if strings.Contains(e, "ForensicPage") {
+				pages++
+			}
+			if strings.Contains(e, "ForensicCaptureSkipped") {
+				skips++
+			}
+		}
+		if pages != 1 || skips != 1 {
+			t.Errorf("case fails %v: got %d pages and %d skip events, want one each", caseFails, pages, skips)
+		}
+		if kube.reads != 1 {
+			t.Errorf("case fails %v: got %d log reads, want the crash log once", caseFails, kube.reads)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kube-forensics-controller/pkg/manifest"
+)
+
+const (
+	// AnnotationManifest names the ConfigMap holding the signed manifest of a capture
+	AnnotationManifest = "forensic.io/manifest"
+	// ManifestKey is the key of the signed manifest in its ConfigMap and in storage
+	ManifestKey = "manifest.json"
+	// ManifestPublicKey is the key of the signer's public key next to the manifest
+	ManifestPublicKey = "public.pem"
+
+	signingPrivateKey = "private.pem"
+	signingPublicKey  = "public.pem"
+)
+
+// signingKey returns the manifest signing key, creating its Secret on first use.
+// The Secret lives in the controller's own namespace, not in the target
+// namespace, so that access to the evidence does not grant access to the key.
+// Reads bypass the cache so the key is never held by a cluster-wide informer.
+func (r *PodReconciler) signingKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	r.signingMu.Lock()
+	defer r.signingMu.Unlock()
+	if r.signer != nil {
+		return r.signer, nil
+	}
+
+	secrets := r.KubeClient.CoreV1().Secrets(r.Config.SigningKeyNamespace)
+	secret, err := secrets.Get(ctx, r.Config.SigningKeySecret, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		privPEM, pubPEM, genErr := manifest.GenerateKey()
+		if genErr != nil {
+			return nil, genErr
+		}
+		immutable := true
+		secret, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.Config.SigningKeySecret,
+				Namespace: r.Config.SigningKeyNamespace,
+			},
+			Immutable: &immutable,
+			Data: map[string][]byte{
+				signingPrivateKey: privPEM,
+				signingPublicKey:  pubPEM,
+			},
+		}, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			// Another replica won the race
+			secret, err = secrets.Get(ctx, r.Config.SigningKeySecret, metav1.GetOptions{})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := manifest.ParsePrivateKey(secret.Data[signingPrivateKey])
+	if err != nil {
+		return nil, fmt.Errorf("signing key secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	r.signer = key
+	return key, nil
+}
+
+// buildManifest lists every artifact of a capture with its SHA-256
+func (r *PodReconciler) buildManifest(ctx context.Context, pod *corev1.Pod, caseName string, signature string, logs []capturedLog, evidence *logEvidence, evidenceBundle []byte, bundleURL string, resourceMap map[string]string, snapshotMap map[string]string, checkpointLocation string) *manifest.Manifest {
+	m := &manifest.Manifest{
+		Version:        1,
+		CreatedAt:      time.Now().UTC(),
+		Case:           caseName,
+		SourcePod:      manifest.PodRef{Namespace: pod.Namespace, Name: pod.Name, UID: string(pod.UID)},
+		CrashSignature: signature,
+	}
+
+	// 1. Logs
+	for _, l := range logs {
+		a := manifest.Artifact{
+			Kind:     manifest.KindLog,
+			Name:     l.Key,
+			Location: l.ExportURL,
+			Size:     int64(len(l.Data)),
+			SHA256:   l.sha256(),
+		}
+		if a.Location == "" && evidence != nil {
+			a.Location = fmt.Sprintf("configmap/%s", strings.Join(evidence.ConfigMaps, ","))
+			if evidence.PVC != "" {
+				a.Location = fmt.Sprintf("pvc/%s", evidence.PVC)
+			}
+		}
+		m.Artifacts = append(m.Artifacts, a)
+	}
+
+	// 2. Evidence bundle
+	if evidenceBundle != nil {
+		m.Artifacts = append(m.Artifacts, manifest.Artifact{
+			Kind:     manifest.KindBundle,
+			Name:     EvidenceBundleName,
+			Location: bundleURL,
+			Size:     int64(len(evidenceBundle)),
+			SHA256:   manifest.Hash(evidenceBundle),
+		})
+	}
+
+	// 3. Cloned ConfigMaps and Secrets, hashed as stored in the target namespace.
+	// They were created moments ago, so they are read past the cache.
+	reader := r.apiReader()
+	var clones []string
+	for src := range resourceMap {
+		clones = append(clones, src)
+	}
+	sort.Strings(clones)
+	for _, src := range clones {
+		dst := resourceMap[src]
+		key := types.NamespacedName{Name: dst, Namespace: r.Config.TargetNamespace}
+		a := manifest.Artifact{Name: dst, Location: fmt.Sprintf("%s/%s", r.Config.TargetNamespace, dst)}
+		var data map[string][]byte
+		if strings.HasPrefix(src, "secret/") {
+			a.Kind = manifest.KindSecret
+			var s corev1.Secret
+			if err := reader.Get(ctx, key, &s); err == nil {
+				data = s.Data
+			}
+		} else {
+			a.Kind = manifest.KindConfigMap
+			var cm corev1.ConfigMap
+			if err := reader.Get(ctx, key, &cm); err == nil {
+				data = configMapData(&cm)
+			}
+		}
+		if data != nil {
+			a.SHA256 = manifest.HashData(data)
+		}
+		m.Artifacts = append(m.Artifacts, a)
+	}
+
+	// 4. Volume snapshots (their content is not readable by the controller)
+	var pvcs []string
+	for pvc := range snapshotMap {
+		pvcs = append(pvcs, pvc)
+	}
+	sort.Strings(pvcs)
+	for _, pvc := range pvcs {
+		m.Artifacts = append(m.Artifacts, manifest.Artifact{
+			Kind:     manifest.KindSnapshot,
+			Name:     pvc,
+			Location: fmt.Sprintf("%s/%s", pod.Namespace, snapshotMap[pvc]),
+		})
+	}
+
+	// 5. Checkpoint tarball (hashed by the collector job, see <location>.sha256)
+	if checkpointLocation != "" {
+		m.Artifacts = append(m.Artifacts, manifest.Artifact{
+			Kind:     manifest.KindCheckpoint,
+			Name:     "checkpoint.tar",
+			Location: checkpointLocation,
+		})
+	}
+
+	return m
+}
+
+// storeManifest signs the manifest and stores it, with the signer's public key,
+// in a ConfigMap of the target namespace. The signed bytes are returned for export.
+func (r *PodReconciler) storeManifest(ctx context.Context, pod *corev1.Pod, m *manifest.Manifest) (string, []byte, error) {
+	key, err := r.signingKey(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	signed, err := manifest.Sign(m, key)
+	if err != nil {
+		return "", nil, err
+	}
+	pubPEM, err := manifest.EncodePublicKey(key.Public().(ed25519.PublicKey))
+	if err != nil {
+		return "", nil, err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-manifest-", pod.Name),
+			Namespace:    r.Config.TargetNamespace,
+			Labels: map[string]string{
+				LabelSourcePodUID: string(pod.UID),
+			},
+		},
+		Data: map[string]string{
+			ManifestKey:       string(signed),
+			ManifestPublicKey: string(pubPEM),
+		},
+	}
+	if err := r.Create(ctx, cm); err != nil {
+		return "", nil, err
+	}
+	return cm.Name, signed, nil
+}
+
+// configMapData merges text and binary keys the same way verification does
+func configMapData(cm *corev1.ConfigMap) map[string][]byte {
+	data := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+	for k, v := range cm.Data {
+		data[k] = []byte(v)
+	}
+	for k, v := range cm.BinaryData {
+		data[k] = v
+	}
+	return data
+}
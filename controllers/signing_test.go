@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kube-forensics-controller/pkg/manifest"
+)
+
+func TestStoreManifestSignsWithOwnedKey(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	clone := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "forensic-app-config", Namespace: "debug-forensics"},
+		Data:       map[string]string{"LEVEL": "debug"},
+	}
+	kube := kubefake.NewSimpleClientset()
+	r := &PodReconciler{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(clone).Build(),
+		KubeClient: kube,
+		Config: ForensicsConfig{
+			TargetNamespace:     "debug-forensics",
+			SigningKeySecret:    "signing-key",
+			SigningKeyNamespace: "forensics-system",
+		},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid-1"}}
+	logs := []capturedLog{{Key: "app.current.log", Data: "panic: boom\n"}}
+
+	m := r.buildManifest(context.Background(), pod, "web-abcde", "sig", logs, nil, nil, "", map[string]string{"cm/app-config": clone.Name}, nil, "")
+	cmName, signed, err := r.storeManifest(context.Background(), pod, m)
+	if err != nil {
+		t.Fatalf("storeManifest: %v", err)
+	}
+
+	// The key Secret is created in the controller's namespace, not the target namespace
+	secret, err := kube.CoreV1().Secrets("forensics-system").Get(context.Background(), "signing-key", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("signing key secret not created: %v", err)
+	}
+	pub, err := manifest.ParsePublicKey(secret.Data[signingPublicKey])
+	if err != nil {
+		t.Fatalf("public key: %v", err)
+	}
+
+	got, err := manifest.Verify(signed, pub)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(got.Artifacts) != 2 {
+		t.Fatalf("got %d artifacts, want 2: %+v", len(got.Artifacts), got.Artifacts)
+	}
+	if got.Artifacts[1].SHA256 != manifest.HashData(map[string][]byte{"LEVEL": []byte("debug")}) {
+		t.Errorf("cloned ConfigMap hash mismatch")
+	}
+
+	var cm corev1.ConfigMap
+	if err := r.Get(context.Background(), types.NamespacedName{Name: cmName, Namespace: "debug-forensics"}, &cm); err != nil {
+		t.Fatalf("manifest ConfigMap: %v", err)
+	}
+	if cm.Data[ManifestKey] != string(signed) {
+		t.Errorf("stored manifest differs from the signed one")
+	}
+
+	// The key is reused for later captures
+	if _, _, err := r.storeManifest(context.Background(), pod, m); err != nil {
+		t.Fatalf("second storeManifest: %v", err)
+	}
+	secrets, _ := kube.CoreV1().Secrets("forensics-system").List(context.Background(), metav1.ListOptions{})
+	if len(secrets.Items) != 1 {
+		t.Errorf("got %d signing key secrets, want 1", len(secrets.Items))
+	}
+}
+
+func TestBuildManifestHashesClonesPastTheCache(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	clone := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "default-db-creds", Namespace: "debug-forensics"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	r := &PodReconciler{
+		// The cache has not seen the clone yet
+		Client:    fake.NewClientBuilder().WithScheme(scheme).Build(),
+		APIReader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(clone).Build(),
+		Config:    ForensicsConfig{TargetNamespace: "debug-forensics"},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid-1"}}
+
+	m := r.buildManifest(context.Background(), pod, "", "sig", nil, nil, nil, "", map[string]string{"secret/db-creds": clone.Name}, nil, "")
+	if len(m.Artifacts) != 1 || m.Artifacts[0].Kind != manifest.KindSecret {
+		t.Fatalf("got artifacts %+v", m.Artifacts)
+	}
+	if want := manifest.HashData(clone.Data); m.Artifacts[0].SHA256 != want {
+		t.Errorf("cloned Secret hash = %q, want %q", m.Artifacts[0].SHA256, want)
+	}
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+	"kube-forensics-controller/pkg/manifest"
+)
+
+// Reasons of pods that never start
+const (
+	ReasonImagePullBackOff           = "ImagePullBackOff"
+	ReasonErrImagePull               = "ErrImagePull"
+	ReasonCreateContainerConfigError = "CreateContainerConfigError"
+	ReasonUnschedulable              = corev1.PodReasonUnschedulable
+)
+
+// DefaultUnschedulableThreshold is how long a pod may stay unschedulable
+// before it is captured
+const DefaultUnschedulableThreshold = 5 * time.Minute
+
+// maxStartupEvents caps the events recorded on a startup failure case
+const maxStartupEvents = 20
+
+// startupFailure is a pod that cannot start
+type startupFailure struct {
+	Container string // Empty for unschedulable pods
+	Reason    string
+	Message   string
+}
+
+// detectStartupFailure finds why a pod cannot start. Init containers are
+// checked first, since they block the others. An unschedulable pod is only a
+// failure once it has been unschedulable for the threshold; until then the
+// time left is returned, so the pod can be checked again.
+func detectStartupFailure(pod *corev1.Pod, threshold time.Duration, now time.Time) (*startupFailure, time.Duration) {
+	if pod.Status.Phase != corev1.PodPending {
+		return nil, 0
+	}
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		waiting := status.State.Waiting
+		if waiting == nil {
+			continue
+		}
+		switch waiting.Reason {
+		case ReasonImagePullBackOff, ReasonErrImagePull, ReasonCreateContainerConfigError:
+			return &startupFailure{Container: status.Name, Reason: waiting.Reason, Message: waiting.Message}, 0
+		}
+	}
+
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type != corev1.PodScheduled || cond.Status != corev1.ConditionFalse || cond.Reason != ReasonUnschedulable {
+			continue
+		}
+		if pending := now.Sub(cond.LastTransitionTime.Time); pending < threshold {
+			return nil, threshold - pending
+		}
+		return &startupFailure{Reason: ReasonUnschedulable, Message: cond.Message}, 0
+	}
+	return nil, 0
+}
+
+// getStartupSignature is the crash signature of a startup failure. ErrImagePull
+// and ImagePullBackOff alternate while the kubelet retries, so they share one.
+func (r *PodReconciler) getStartupSignature(pod *corev1.Pod, workload string, failure *startupFailure) string {
+	reason := failure.Reason
+	if reason == ReasonErrImagePull {
+		reason = ReasonImagePullBackOff
+	}
+	// Input: Namespace + WorkloadName + ContainerName + Reason
+	return signatureOf(fmt.Sprintf("%s-%s-%s-%s", pod.Namespace, workload, failure.Container, reason))
+}
+
+// captureStartupFailure records a pod that never started in a ForensicCase:
+// the waiting reason, the events, the missing references and the scheduler's
+// messages. There is nothing to run, so no forensic pod is created, and the
+// case is the only record; the capture fails if it cannot be created.
+func (r *PodReconciler) captureStartupFailure(ctx context.Context, pod *corev1.Pod, failure *startupFailure) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	cfg, policyName, err := r.resolveConfig(ctx, pod)
+	if err != nil {
+		logger.Error(err, "Failed to resolve forensic policy (using global config)")
+	}
+
+	// 1. Deduplication
+	// One case per pod, and one per signature within the rate limit window
+	workload, err := r.resolveWorkload(ctx, pod)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	signature := r.getStartupSignature(pod, workload.String(), failure)
+	var cases forensicv1alpha1.ForensicCaseList
+	if err := r.List(ctx, &cases, client.InNamespace(r.Config.TargetNamespace), client.MatchingLabels{LabelCrashSignature: signature}); err != nil {
+		return ctrl.Result{}, err
+	}
+	caseName := forensicCaseName(pod, failure.Container)
+	now := time.Now()
+	for _, fc := range cases.Items {
+		if fc.Name == caseName || now.Sub(fc.CreationTimestamp.Time) < cfg.RateLimitWindow {
+			logger.V(1).Info("Skipping startup failure capture (already captured)", "pod", client.ObjectKeyFromObject(pod), "case", fc.Name)
+			return ctrl.Result{}, nil
+		}
+	}
+
+	logger.Info("Detected startup failure", "pod", client.ObjectKeyFromObject(pod), "reason", failure.Reason)
+	ForensicCrashesTotal.WithLabelValues(pod.Namespace, "StartupFailure").Inc()
+
+	// 2. Record the Case
+	if err := r.ensureNamespace(ctx); err != nil {
+		ForensicPodCreationErrorsTotal.WithLabelValues(pod.Namespace, "EnsureNamespace").Inc()
+		return ctrl.Result{}, err
+	}
+	fcase, err := r.createForensicCase(ctx, pod, signature, workload, failure.Container, 0, failure.Reason, policyName)
+	if err != nil {
+		ForensicPodCreationErrorsTotal.WithLabelValues(pod.Namespace, "CreateForensicCase").Inc()
+		return ctrl.Result{}, err
+	}
+	defer r.saveCaseStatus(ctx, fcase)
+
+	sf := &forensicv1alpha1.StartupFailure{Message: failure.Message}
+	fcase.Status.StartupFailure = sf
+	if workload.Job != nil {
+		fcase.Status.Job = jobContext(workload.Job)
+	}
+
+	// 3. Missing ConfigMaps, Secrets, PVCs and keys
+	sf.MissingReferences, err = r.missingReferences(ctx, pod)
+	if err != nil {
+		logger.Error(err, "Failed to check the references of the pod")
+	}
+
+	// 4. Events and scheduler messages
+	events, err := r.KubeClient.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.uid", string(pod.UID)).String(),
+	})
+	if err != nil {
+		logger.Error(err, "Failed to list the events of the pod")
+	} else {
+		sf.Events, sf.SchedulingMessages = summarizeEvents(events.Items)
+	}
+
+	// 5. Evidence Bundle
+	if !cfg.EnableExport {
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionUploaded, metav1.ConditionFalse, "ExportDisabled", "Export is disabled by policy")
+	} else if evidenceBundle, err := r.buildEvidenceBundle(ctx, pod).Bytes(); err != nil {
+		logger.Error(err, "Failed to assemble evidence bundle")
+		r.setCaseCondition(fcase, forensicv1alpha1.ConditionUploaded, metav1.ConditionFalse, "UploadFailed", err.Error())
+	} else {
+		key := fmt.Sprintf("%s/%s", r.capturePrefix(pod, now), EvidenceBundleName)
+		meta := r.artifactMetadata(pod, failure.Container, nil, signature, manifest.Hash(evidenceBundle))
+		url, err := r.Storage.Upload(ctx, key, evidenceBundle, meta)
+		switch {
+		case err != nil:
+			logger.Error(err, "Failed to upload evidence bundle to storage")
+			r.Recorder.Eventf(pod, corev1.EventTypeWarning, exportFailedReason(err), "Failed to upload evidence bundle to storage: %v", err)
+			r.setCaseCondition(fcase, forensicv1alpha1.ConditionUploaded, metav1.ConditionFalse, "UploadFailed", err.Error())
+		case url != "":
+			fcase.Status.EvidenceBundleURL = url
+			r.setCaseCondition(fcase, forensicv1alpha1.ConditionUploaded, metav1.ConditionTrue, "Uploaded", fmt.Sprintf("Uploaded evidence bundle to %s", url))
+		default:
+			r.setCaseCondition(fcase, forensicv1alpha1.ConditionUploaded, metav1.ConditionFalse, "ExportDisabled", "No storage backend is configured")
+		}
+	}
+
+	r.setCaseCondition(fcase, forensicv1alpha1.ConditionForensicPodRunning, metav1.ConditionFalse, "StartupFailure", "No forensic pod is created for a pod that never started")
+	r.Recorder.Eventf(pod, corev1.EventTypeWarning, "ForensicStartupFailure", "Pod cannot start (%s), recorded in forensic case %s", failure.Reason, fcase.Name)
+	return ctrl.Result{}, nil
+}
+
+// summarizeEvents formats events oldest first, keeping the most recent
+// maxStartupEvents, and collects the distinct FailedScheduling messages
+func summarizeEvents(events []corev1.Event) (lines []string, scheduling []string) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+	seen := make(map[string]bool)
+	for _, e := range events {
+		line := fmt.Sprintf("%s %s: %s", e.Type, e.Reason, e.Message)
+		if e.Count > 1 {
+			line += fmt.Sprintf(" (x%d)", e.Count)
+		}
+		lines = append(lines, line)
+		if e.Reason == "FailedScheduling" && !seen[e.Message] {
+			seen[e.Message] = true
+			scheduling = append(scheduling, e.Message)
+		}
+	}
+	if len(lines) > maxStartupEvents {
+		lines = lines[len(lines)-maxStartupEvents:]
+	}
+	return lines, scheduling
+}
+
+// eventTime is when an event last occurred
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	}
+	return e.FirstTimestamp.Time
+}
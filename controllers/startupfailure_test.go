@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+)
+
+func TestDetectStartupFailure(t *testing.T) {
+	now := time.Now()
+	waiting := func(reason string) corev1.PodStatus {
+		return corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "api",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: "details"}},
+			}},
+		}
+	}
+	unschedulable := func(since time.Duration) corev1.PodStatus {
+		return corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodScheduled,
+				Status:             corev1.ConditionFalse,
+				Reason:             ReasonUnschedulable,
+				Message:            "0/3 nodes are available: 3 Insufficient memory.",
+				LastTransitionTime: metav1.NewTime(now.Add(-since)),
+			}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		status     corev1.PodStatus
+		wantReason string
+		wantWait   time.Duration
+	}{
+		{"image pull backoff", waiting(ReasonImagePullBackOff), ReasonImagePullBackOff, 0},
+		{"missing config", waiting(ReasonCreateContainerConfigError), ReasonCreateContainerConfigError, 0},
+		{"starting", waiting("ContainerCreating"), "", 0},
+		{"crash loop", waiting(ReasonCrashLoopBackOff), "", 0},
+		{"unschedulable for long", unschedulable(10 * time.Minute), ReasonUnschedulable, 0},
+		{"unschedulable for now", unschedulable(time.Minute), "", 4 * time.Minute},
+		{"running", corev1.PodStatus{Phase: corev1.PodRunning}, "", 0},
+	}
+	for _, tt := range tests {
+		failure, wait := detectStartupFailure(&corev1.Pod{Status: tt.status}, 5*time.Minute, now)
+		reason := ""
+		if failure != nil {
+			reason = failure.Reason
+		}
+		if reason != tt.wantReason || wait != tt.wantWait {
+			t.Errorf("%s: got (%q, %s), want (%q, %s)", tt.name, reason, wait, tt.wantReason, tt.wantWait)
+		}
+	}
+}
+
+func TestCaptureStartupFailure(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = forensicv1alpha1.AddToScheme(scheme)
+
+	optional := true
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", UID: "api-uid"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "api",
+				Env: []corev1.EnvVar{
+					{Name: "LOG_LEVEL", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}, Key: "LOG_LEVEL"}}},
+					{Name: "DATABASE_URL", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"}, Key: "DATABASE_URL"}}},
+					{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "api-token"}, Key: "token", Optional: &optional}}},
+				},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         "data",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "api-data"}},
+			}},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "api",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+					Reason:  ReasonCreateContainerConfigError,
+					Message: `couldn't find key DATABASE_URL in ConfigMap default/app-config`,
+				}},
+			}},
+		},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "default"},
+		Data:       map[string]string{"LOG_LEVEL": "debug"},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "api.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "api", Namespace: "default", UID: "api-uid"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "Failed",
+		Message:        "Error: couldn't find key DATABASE_URL in ConfigMap default/app-config",
+		Count:          3,
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(pod, cm).
+		WithStatusSubresource(&forensicv1alpha1.ForensicCase{}).
+		Build()
+	r := &PodReconciler{
+		Client:     c,
+		Scheme:     scheme,
+		KubeClient: kubefake.NewSimpleClientset(event),
+		Recorder:   record.NewFakeRecorder(10),
+		Config: ForensicsConfig{
+			TargetNamespace:        "debug-forensics",
+			RateLimitWindow:        time.Hour,
+			CaptureStartupFailures: true,
+			UnschedulableThreshold: DefaultUnschedulableThreshold,
+		},
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile %d: %v", i, err)
+		}
+	}
+
+	var cases forensicv1alpha1.ForensicCaseList
+	if err := c.List(ctx, &cases, client.InNamespace("debug-forensics")); err != nil {
+		t.Fatal(err)
+	}
+	if len(cases.Items) != 1 {
+		t.Fatalf("got %d cases, want exactly one", len(cases.Items))
+	}
+	fc := cases.Items[0]
+	if fc.Spec.Reason != ReasonCreateContainerConfigError || fc.Spec.Container != "api" {
+		t.Errorf("spec = %+v, want a CreateContainerConfigError of container api", fc.Spec)
+	}
+	sf := fc.Status.StartupFailure
+	if sf == nil {
+		t.Fatal("status.startupFailure not recorded")
+	}
+	if want := []string{"pvc/api-data", "configmap/app-config:DATABASE_URL"}; !reflect.DeepEqual(sf.MissingReferences, want) {
+		t.Errorf("missing references %v, want %v", sf.MissingReferences, want)
+	}
+	if !strings.Contains(sf.Message, "DATABASE_URL") {
+		t.Errorf("message %q, want the waiting message", sf.Message)
+	}
+	if want := []string{"Warning Failed: " + event.Message + " (x3)"}; !reflect.DeepEqual(sf.Events, want) {
+		t.Errorf("events %v, want %v", sf.Events, want)
+	}
+	if cond := meta.FindStatusCondition(fc.Status.Conditions, forensicv1alpha1.ConditionForensicPodRunning); cond == nil || cond.Reason != "StartupFailure" {
+		t.Errorf("ForensicPodRunning condition %+v, want reason StartupFailure", cond)
+	}
+
+	var pods corev1.PodList
+	if err := c.List(ctx, &pods, client.InNamespace("debug-forensics")); err != nil {
+		t.Fatal(err)
+	}
+	if len(pods.Items) != 0 {
+		t.Errorf("created %d forensic pods for a pod that never started", len(pods.Items))
+	}
+}
+
+func TestSummarizeEvents(t *testing.T) {
+	now := time.Now()
+	events := []corev1.Event{
+		{Type: "Warning", Reason: "FailedScheduling", Message: "0/3 nodes are available: 3 Insufficient memory.", LastTimestamp: metav1.NewTime(now)},
+		{Type: "Normal", Reason: "NotTriggerScaleUp", Message: "pod didn't trigger scale-up", LastTimestamp: metav1.NewTime(now.Add(-time.Minute))},
+		{Type: "Warning", Reason: "FailedScheduling", Message: "0/3 nodes are available: 3 Insufficient memory.", LastTimestamp: metav1.NewTime(now.Add(-2 * time.Minute))},
+	}
+	lines, scheduling := summarizeEvents(events)
+	if want := []string{"0/3 nodes are available: 3 Insufficient memory."}; !reflect.DeepEqual(scheduling, want) {
+		t.Errorf("scheduling messages %v, want %v", scheduling, want)
+	}
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "Normal NotTriggerScaleUp") {
+		t.Errorf("events not sorted oldest first: %v", lines)
+	}
+}
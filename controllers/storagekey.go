@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"kube-forensics-controller/pkg/storage"
+	"kube-forensics-controller/pkg/version"
+)
+
+// DefaultStorageKeyTemplate lays out artifacts as <namespace>/<pod>/<timestamp>/<file>
+const DefaultStorageKeyTemplate = "{{.Namespace}}/{{.Pod}}/{{.Timestamp}}"
+
+// storageKeyData is the input of the storage key template
+type storageKeyData struct {
+	Cluster   string
+	Namespace string
+	Pod       string
+	UID       string
+	Timestamp string // 2006/01/02/150405
+	Date      string // 2006-01-02
+}
+
+// ParseStorageKeyTemplate parses the template of the storage prefix of a
+// capture. It is checked against a sample pod so that a broken template fails
+// at startup rather than at the first crash.
+func ParseStorageKeyTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("storage-key").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(text, ".Timestamp") {
+		return nil, fmt.Errorf("storage key template %q must contain {{.Timestamp}} to keep captures apart", text)
+	}
+	sample := storageKeyData{Cluster: "prod", Namespace: "default", Pod: "web", UID: "uid", Timestamp: "2006/01/02/150405", Date: "2006-01-02"}
+	if _, err := renderStorageKey(tmpl, sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+func renderStorageKey(tmpl *template.Template, data storageKeyData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	prefix := strings.Trim(buf.String(), "/")
+	if prefix == "" || strings.Contains("/"+prefix+"/", "/../") {
+		return "", fmt.Errorf("storage key template rendered an invalid prefix %q", buf.String())
+	}
+	return prefix, nil
+}
+
+// capturePrefix is the storage prefix shared by all artifacts of one capture
+func (r *PodReconciler) capturePrefix(pod *corev1.Pod, now time.Time) string {
+	tmpl := r.Config.StorageKeyTemplate
+	if tmpl == nil {
+		tmpl = template.Must(ParseStorageKeyTemplate(DefaultStorageKeyTemplate))
+	}
+	now = now.UTC()
+	prefix, err := renderStorageKey(tmpl, storageKeyData{
+		Cluster:   r.Config.ClusterName,
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		UID:       string(pod.UID),
+		Timestamp: now.Format("2006/01/02/150405"),
+		Date:      now.Format("2006-01-02"),
+	})
+	if err != nil {
+		// Only reachable with data-dependent templates; fall back to the default layout
+		return fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, now.Format("2006/01/02/150405"))
+	}
+	return prefix
+}
+
+// artifactMetadata describes an exported artifact of a pod, so that it can be
+// identified in the bucket once the forensic pod and its annotations are gone.
+// exitCode is nil for artifacts without a crash, e.g. on-demand checkpoints.
+func (r *PodReconciler) artifactMetadata(pod *corev1.Pod, container string, exitCode *int32, signature string, sha256 string) storage.Metadata {
+	meta := storage.Metadata{
+		storage.MetaNamespace:         pod.Namespace,
+		storage.MetaPod:               pod.Name,
+		storage.MetaPodUID:            string(pod.UID),
+		storage.MetaControllerVersion: version.Version,
+	}
+	if exitCode != nil {
+		meta[storage.MetaExitCode] = fmt.Sprintf("%d", *exitCode)
+	}
+	return meta.
+		With(storage.MetaContainer, container).
+		With(storage.MetaCrashSignature, signature).
+		With(storage.MetaSHA256, sha256).
+		With(storage.MetaCluster, r.Config.ClusterName)
+}
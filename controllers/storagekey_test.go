@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseStorageKeyTemplate(t *testing.T) {
+	for _, text := range []string{
+		"{{.Namespace}}/{{.Pod}}",
+		"{{.Missing}}/{{.Timestamp}}",
+		"{{.Namespace}/{{.Timestamp}}",
+		"../{{.Timestamp}}",
+	} {
+		if _, err := ParseStorageKeyTemplate(text); err == nil {
+			t.Errorf("ParseStorageKeyTemplate(%q) should fail", text)
+		}
+	}
+	if _, err := ParseStorageKeyTemplate(DefaultStorageKeyTemplate); err != nil {
+		t.Errorf("default template: %v", err)
+	}
+}
+
+func TestCapturePrefix(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod", UID: "1234"}}
+	now := time.Date(2026, 1, 2, 10, 4, 5, 0, time.UTC)
+
+	r := &PodReconciler{}
+	if got := r.capturePrefix(pod, now); got != "prod/web/2026/01/02/100405" {
+		t.Errorf("default prefix = %q", got)
+	}
+
+	tmpl, err := ParseStorageKeyTemplate("/{{.Cluster}}/{{.Date}}/{{.Namespace}}/{{.UID}}/{{.Timestamp}}/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Config = ForensicsConfig{StorageKeyTemplate: tmpl, ClusterName: "eu-1"}
+	if got := r.capturePrefix(pod, now); got != "eu-1/2026-01-02/prod/1234/2026/01/02/100405" {
+		t.Errorf("custom prefix = %q", got)
+	}
+}
+
+func TestArtifactMetadata(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod", UID: "1234"}}
+	r := &PodReconciler{Config: ForensicsConfig{ClusterName: "eu-1"}}
+
+	exitCode := int32(137)
+	meta := r.artifactMetadata(pod, "app", &exitCode, "sig", "abc")
+	for k, want := range map[string]string{
+		"source-namespace": "prod",
+		"source-pod":       "web",
+		"source-pod-uid":   "1234",
+		"container":        "app",
+		"exit-code":        "137",
+		"crash-signature":  "sig",
+		"sha256":           "abc",
+		"cluster":          "eu-1",
+	} {
+		if meta[k] != want {
+			t.Errorf("%s = %q, want %q", k, meta[k], want)
+		}
+	}
+
+	// The exit code does not depend on the signature
+	exitCode = 0
+	meta = r.artifactMetadata(pod, "app", &exitCode, "", "")
+	if meta["exit-code"] != "0" {
+		t.Errorf("exit-code = %q without a signature, want 0", meta["exit-code"])
+	}
+
+	// An on-demand checkpoint has no crash
+	meta = r.artifactMetadata(pod, "app", nil, "", "")
+	if _, ok := meta["exit-code"]; ok {
+		t.Errorf("exit-code set without a crash: %v", meta)
+	}
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// LogTruncationHead keeps the first MaxLogSizeBytes of a log
+	LogTruncationHead = "head"
+	// LogTruncationHeadTail keeps LogHeadBytes from the start and the rest of MaxLogSizeBytes from the end
+	LogTruncationHeadTail = "head-tail"
+)
+
+// ValidateLogTruncation checks the log truncation flags at startup
+func ValidateLogTruncation(mode string, maxBytes int64, headBytes int64) error {
+	if mode != LogTruncationHead && mode != LogTruncationHeadTail {
+		return fmt.Errorf("unknown log truncation mode %q", mode)
+	}
+	if maxBytes <= 0 {
+		return fmt.Errorf("max log size must be positive, got %d", maxBytes)
+	}
+	if headBytes < 0 {
+		return fmt.Errorf("log head size must not be negative, got %d", headBytes)
+	}
+	if mode == LogTruncationHeadTail && headBytes >= maxBytes {
+		return fmt.Errorf("log head size (%d) must be smaller than the max log size (%d) in %s mode", headBytes, maxBytes, mode)
+	}
+	return nil
+}
+
+// readHeadTail streams r to the end and keeps at most head bytes from the start
+// and tail bytes from the end. Only head+tail bytes are held in memory: the tail
+// is kept in a ring buffer that is overwritten as the stream advances.
+// If anything was dropped, an elision marker with the dropped byte count is
+// placed between the two windows.
+func readHeadTail(r io.Reader, head int64, tail int64) (string, error) {
+	headBuf := make([]byte, 0, head)
+	ring := make([]byte, tail)
+	var ringPos int64  // Next write position in ring
+	var ringFill int64 // Bytes currently held in ring
+	var elided int64   // Bytes pushed out of the ring
+	var total int64    // Bytes read overall
+
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(chunk)
+		data := chunk[:n]
+		total += int64(n)
+
+		// Fill the head window first
+		if room := head - int64(len(headBuf)); room > 0 && len(data) > 0 {
+			take := int64(len(data))
+			if take > room {
+				take = room
+			}
+			headBuf = append(headBuf, data[:take]...)
+			data = data[take:]
+		}
+
+		// Everything else goes through the tail ring
+		if tail == 0 {
+			elided += int64(len(data))
+		} else {
+			if int64(len(data)) > tail {
+				// Only the last tail bytes of this chunk can survive
+				skip := int64(len(data)) - tail
+				elided += skip
+				data = data[skip:]
+			}
+			for len(data) > 0 {
+				c := copy(ring[ringPos:], data)
+				data = data[c:]
+				ringPos = (ringPos + int64(c)) % tail
+				if ringFill+int64(c) > tail {
+					elided += ringFill + int64(c) - tail
+					ringFill = tail
+				} else {
+					ringFill += int64(c)
+				}
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var out strings.Builder
+	out.Grow(len(headBuf) + int(ringFill) + 64)
+	out.Write(headBuf)
+	if elided > 0 {
+		out.WriteString(fmt.Sprintf("\n... [TRUNCATED: %d bytes elided of %d] ...\n", elided, total))
+	}
+	if ringFill < tail {
+		out.Write(ring[:ringFill])
+	} else {
+		// Ring is full: the oldest byte sits at ringPos
+		out.Write(ring[ringPos:])
+		out.Write(ring[:ringPos])
+	}
+	return out.String(), nil
+}
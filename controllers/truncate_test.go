@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadHeadTail(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		head  int64
+		tail  int64
+		want  string
+	}{
+		{"fits", "hello world", 4, 16, "hello world"},
+		{"exact fit", "hello world", 5, 6, "hello world"},
+		{"elided", "0123456789abcdefghij", 4, 4, "0123\n... [TRUNCATED: 12 bytes elided of 20] ...\nghij"},
+		{"tail only", "0123456789", 0, 3, "\n... [TRUNCATED: 7 bytes elided of 10] ...\n789"},
+		{"head only", "0123456789", 3, 0, "012\n... [TRUNCATED: 7 bytes elided of 10] ...\n"},
+		{"empty", "", 4, 4, ""},
+	}
+
+	for _, tt := range tests {
+		got, err := readHeadTail(strings.NewReader(tt.input), tt.head, tt.tail)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadHeadTailKeepsStackTraceOfLargeStream(t *testing.T) {
+	// Many small reads force the tail ring to wrap around repeatedly
+	body := strings.Repeat("INFO processing request\n", 50000)
+	trace := "panic: runtime error: invalid memory address\ngoroutine 1 [running]:\nmain.main()\n"
+	input := "Starting app\n" + body + trace
+
+	got, err := readHeadTail(iotest.OneByteReader(strings.NewReader(input)), 13, int64(len(trace)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(got, "Starting app\n") {
+		t.Errorf("head window lost: %q", got[:32])
+	}
+	if !strings.HasSuffix(got, trace) {
+		t.Errorf("tail window lost the stack trace: %q", got[len(got)-len(trace):])
+	}
+	if !strings.Contains(got, "[TRUNCATED: 1200000 bytes elided") {
+		t.Errorf("missing elision marker with dropped byte count")
+	}
+}
+
+func TestValidateLogTruncation(t *testing.T) {
+	tests := []struct {
+		mode    string
+		max     int64
+		head    int64
+		wantErr bool
+	}{
+		{LogTruncationHead, 1024, 64, false},
+		{LogTruncationHead, 1024, 4096, false}, // Head size is unused in head mode
+		{LogTruncationHeadTail, 1024, 64, false},
+		{LogTruncationHeadTail, 1024, 0, false},
+		{LogTruncationHeadTail, 1024, 1024, true},
+		{LogTruncationHeadTail, 1024, -1, true},
+		{LogTruncationHead, 1024, -1, true},
+		{LogTruncationHead, 0, 0, true},
+		{"tail", 1024, 64, true},
+	}
+	for _, tt := range tests {
+		err := ValidateLogTruncation(tt.mode, tt.max, tt.head)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateLogTruncation(%q, %d, %d) = %v, wantErr %v", tt.mode, tt.max, tt.head, err, tt.wantErr)
+		}
+	}
+}
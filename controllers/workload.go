@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+)
+
+const (
+	// LabelWorkload names the top-level workload of the source pod on forensic
+	// pods and cases, e.g. the Deployment rather than its ReplicaSet
+	LabelWorkload = "forensic.io/workload"
+
+	annotationRolloutRevision = "rollout.argoproj.io/revision"
+	labelRolloutsTemplateHash = "rollouts-pod-template-hash"
+
+	// maxOwnerDepth bounds the owner chain, e.g. Pod -> Job -> CronJob
+	maxOwnerDepth = 5
+)
+
+// rolloutGVK is the Argo Rollouts kind. Rollouts are read as unstructured, so
+// that the controller does not depend on the Argo API types.
+var rolloutGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+
+// owner is a controller of a pod or of one of its owners. Object is nil for
+// owners of an unknown kind and for owners that could not be read.
+type owner struct {
+	Kind     string
+	Name     string
+	Revision string
+	Object   interface{}
+}
+
+// workloadIdentity is the top-level workload of a pod, which stays the same
+// across rollouts, and the revision the pod was created from
+type workloadIdentity struct {
+	Kind            string
+	Name            string
+	PodTemplateHash string
+	Revision        string
+
+	// Job is the Job of the pod, nil for other pods
+	Job *batchv1.Job
+}
+
+// String is the workload in crash signatures, e.g. Deployment/web
+func (w workloadIdentity) String() string {
+	return w.Kind + "/" + w.Name
+}
+
+// Reference is the workload as recorded on a ForensicCase
+func (w workloadIdentity) Reference() *forensicv1alpha1.WorkloadReference {
+	return &forensicv1alpha1.WorkloadReference{
+		Kind:            w.Kind,
+		Name:            w.Name,
+		PodTemplateHash: w.PodTemplateHash,
+		Revision:        w.Revision,
+	}
+}
+
+// Label is the workload name as a label value
+func (w workloadIdentity) Label() string {
+	name := w.Name
+	if len(name) > validation.LabelValueMaxLength {
+		name = name[:validation.LabelValueMaxLength]
+	}
+	return strings.TrimRight(name, "-.")
+}
+
+// resolveOwners walks the controller owner chain of a pod, closest owner
+// first, e.g. ReplicaSet -> Deployment or Job -> CronJob. Owners of an unknown
+// kind end the chain. An owner that cannot be read ends it as well and is
+// returned by name along with the error. Reads go straight to the API server
+// so that no informers are started for workloads.
+func (r *PodReconciler) resolveOwners(ctx context.Context, pod *corev1.Pod) ([]owner, error) {
+	var owners []owner
+	refs := pod.OwnerReferences
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		ref := metav1.GetControllerOfNoCopy(&metav1.ObjectMeta{OwnerReferences: refs})
+		if ref == nil {
+			return owners, nil
+		}
+
+		o := owner{Kind: ref.Kind, Name: ref.Name}
+		var err error
+		switch ref.Kind {
+		case "ReplicaSet":
+			var rs *appsv1.ReplicaSet
+			if rs, err = r.KubeClient.AppsV1().ReplicaSets(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+				rs.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: ref.Kind}
+				rs.ManagedFields = nil
+				o.Revision = rs.Annotations[annotationDeploymentRevision]
+				if o.Revision == "" {
+					o.Revision = rs.Annotations[annotationRolloutRevision]
+				}
+				o.Object, refs = rs, rs.OwnerReferences
+			}
+		case "Deployment":
+			var d *appsv1.Deployment
+			if d, err = r.KubeClient.AppsV1().Deployments(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+				d.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: ref.Kind}
+				d.ManagedFields = nil
+				o.Revision = d.Annotations[annotationDeploymentRevision]
+				o.Object, refs = d, d.OwnerReferences
+			}
+		case "StatefulSet":
+			var s *appsv1.StatefulSet
+			if s, err = r.KubeClient.AppsV1().StatefulSets(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+				s.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: ref.Kind}
+				s.ManagedFields = nil
+				// The revision the crashed pod was created from
+				o.Revision = pod.Labels[appsv1.ControllerRevisionHashLabelKey]
+				o.Object, refs = s, s.OwnerReferences
+			}
+		case "DaemonSet":
+			var ds *appsv1.DaemonSet
+			if ds, err = r.KubeClient.AppsV1().DaemonSets(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+				ds.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: ref.Kind}
+				ds.ManagedFields = nil
+				o.Revision = pod.Labels[appsv1.ControllerRevisionHashLabelKey]
+				o.Object, refs = ds, ds.OwnerReferences
+			}
+		case "Job":
+			var j *batchv1.Job
+			if j, err = r.KubeClient.BatchV1().Jobs(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+				j.TypeMeta = metav1.TypeMeta{APIVersion: "batch/v1", Kind: ref.Kind}
+				j.ManagedFields = nil
+				o.Object, refs = j, j.OwnerReferences
+			}
+		case "CronJob":
+			var cj *batchv1.CronJob
+			if cj, err = r.KubeClient.BatchV1().CronJobs(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err == nil {
+				cj.TypeMeta = metav1.TypeMeta{APIVersion: "batch/v1", Kind: ref.Kind}
+				cj.ManagedFields = nil
+				o.Object, refs = cj, cj.OwnerReferences
+			}
+		case rolloutGVK.Kind:
+			if !strings.HasPrefix(ref.APIVersion, rolloutGVK.Group+"/") {
+				owners = append(owners, o)
+				return owners, nil
+			}
+			u := &unstructured.Unstructured{}
+			u.SetGroupVersionKind(rolloutGVK)
+			if err = r.apiReader().Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: ref.Name}, u); err == nil {
+				unstructured.RemoveNestedField(u.Object, "metadata", "managedFields")
+				o.Revision = u.GetAnnotations()[annotationRolloutRevision]
+				o.Object, refs = u, u.GetOwnerReferences()
+			}
+		default:
+			// Unknown controllers (operators, ...) are only named
+			owners = append(owners, o)
+			return owners, nil
+		}
+		owners = append(owners, o)
+		if err != nil {
+			return owners, fmt.Errorf("%s %s: %w", ref.Kind, ref.Name, err)
+		}
+	}
+	return owners, nil
+}
+
+// resolveWorkload identifies the top-level workload of a pod. The pods of a
+// Deployment are named after their ReplicaSet, and every run of a CronJob
+// creates a Job with a new name, so the end of the owner chain is what stays
+// the same across rollouts and runs. Standalone pods are their own workload.
+// When an owner is gone, or its kind is not installed, the last owner that
+// is known is used. Other read errors are returned: a partial chain would
+// sign the crash with another workload, e.g. ReplicaSet/web-5d4f rather than
+// Deployment/web, and defeat the deduplication.
+func (r *PodReconciler) resolveWorkload(ctx context.Context, pod *corev1.Pod) (workloadIdentity, error) {
+	owners, err := r.resolveOwners(ctx, pod)
+	if err != nil {
+		if !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return workloadIdentity{}, fmt.Errorf("resolving the owners of the pod: %w", err)
+		}
+		log.FromContext(ctx).Info("Owner chain of the pod is incomplete", "error", err.Error())
+	}
+
+	w := workloadIdentity{Kind: "Pod", Name: pod.GenerateName}
+	if w.Name == "" {
+		w.Name = pod.Name
+	}
+	if len(owners) > 0 {
+		top := owners[len(owners)-1]
+		w.Kind, w.Name = top.Kind, top.Name
+	}
+	for _, o := range owners {
+		if job, ok := o.Object.(*batchv1.Job); ok {
+			w.Job = job
+		}
+		if w.Revision == "" {
+			w.Revision = o.Revision
+		}
+	}
+	for _, key := range []string{appsv1.DefaultDeploymentUniqueLabelKey, labelRolloutsTemplateHash, appsv1.ControllerRevisionHashLabelKey} {
+		if hash := pod.Labels[key]; hash != "" {
+			w.PodTemplateHash = hash
+			break
+		}
+	}
+	return w, nil
+}
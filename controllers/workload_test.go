@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// rollout is one revision of the web Deployment: its ReplicaSet and a pod
+func rollout(hash, revision string) (*appsv1.ReplicaSet, *corev1.Pod) {
+	controller := true
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-" + hash, Namespace: "default",
+		Annotations:     map[string]string{annotationDeploymentRevision: revision},
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}},
+	}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-" + hash + "-abcde", GenerateName: "web-" + hash + "-", Namespace: "default",
+		Labels:          map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: rs.Name, Controller: &controller}},
+	}}
+	return rs, pod
+}
+
+func TestResolveWorkloadAcrossRollouts(t *testing.T) {
+	ctx := context.Background()
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name: "web", Namespace: "default",
+		Annotations: map[string]string{annotationDeploymentRevision: "8"},
+	}}
+	rs1, pod1 := rollout("5d4f", "7")
+	rs2, pod2 := rollout("6b9c", "8")
+	r := &PodReconciler{KubeClient: kubefake.NewSimpleClientset(deploy, rs1, rs2)}
+
+	w1, err := r.resolveWorkload(ctx, pod1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w1.String() != "Deployment/web" || w1.PodTemplateHash != "5d4f" || w1.Revision != "7" {
+		t.Errorf("got %+v, want the Deployment at the revision of the pod's ReplicaSet", w1)
+	}
+	w2, _ := r.resolveWorkload(ctx, pod2)
+	if w2.PodTemplateHash != "6b9c" || w2.Revision != "8" {
+		t.Errorf("got %+v, want the second rollout", w2)
+	}
+	if r.getCrashSignature(pod1, w1.String(), "app", 1, nil) != r.getCrashSignature(pod2, w2.String(), "app", 1, nil) {
+		t.Error("the same crash in two rollouts got different signatures")
+	}
+
+	// Standalone pods are their own workload
+	standalone := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "default"}}
+	if w, _ := r.resolveWorkload(ctx, standalone); w.String() != "Pod/debug" || w.Revision != "" {
+		t.Errorf("got %+v, want the pod itself", w)
+	}
+}
+
+func TestResolveWorkloadRollout(t *testing.T) {
+	controller := true
+	ro := &unstructured.Unstructured{}
+	ro.SetGroupVersionKind(rolloutGVK)
+	ro.SetNamespace("default")
+	ro.SetName("checkout")
+	ro.SetAnnotations(map[string]string{annotationRolloutRevision: "3"})
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "checkout-7c9d", Namespace: "default",
+		Annotations:     map[string]string{annotationRolloutRevision: "3"},
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "checkout", Controller: &controller}},
+	}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "checkout-7c9d-xyz12", Namespace: "default",
+		Labels:          map[string]string{labelRolloutsTemplateHash: "7c9d"},
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: rs.Name, Controller: &controller}},
+	}}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	r := &PodReconciler{
+		KubeClient: kubefake.NewSimpleClientset(rs),
+		APIReader:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(ro).Build(),
+	}
+
+	owners, err := r.resolveOwners(context.Background(), pod)
+	if err != nil {
+		t.Fatalf("resolveOwners: %v", err)
+	}
+	if len(owners) != 2 || owners[1].Kind != "Rollout" || owners[1].Revision != "3" || owners[1].Object == nil {
+		t.Fatalf("got owners %+v, want the ReplicaSet and the Rollout", owners)
+	}
+	w, err := r.resolveWorkload(context.Background(), pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != "Rollout/checkout" || w.PodTemplateHash != "7c9d" || w.Revision != "3" {
+		t.Errorf("got %+v, want the Rollout", w)
+	}
+}
+
+func TestReconcileOwnerReadError(t *testing.T) {
+	rs, pod := rollout("5d4f", "7")
+	pod.Spec.Containers = []corev1.Container{{Name: "app", Image: "web:1"}}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "app",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}},
+	}}
+	kube := kubefake.NewSimpleClientset(rs, pod)
+	kube.PrependReactor("get", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewServiceUnavailable("etcd leader election")
+	})
+	r, c, _ := newCaptureReconciler(kube, pod)
+
+	// The crash is not signed as ReplicaSet/web-5d4f: the reconcile is retried
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pod)}); err == nil {
+		t.Fatal("owner read error not returned")
+	}
+	var forensicPods corev1.PodList
+	if err := c.List(context.Background(), &forensicPods, client.InNamespace(r.Config.TargetNamespace)); err != nil {
+		t.Fatal(err)
+	}
+	if len(forensicPods.Items) != 0 {
+		t.Errorf("got %d forensic pods, want none until the owners can be read", len(forensicPods.Items))
+	}
+
+	// Once the Deployment can be read, the crash is captured under it
+	kube.PrependReactor("get", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}, nil
+	})
+	forensicPods.Items = reconcilePod(t, r, c, pod)
+	if len(forensicPods.Items) != 1 || forensicPods.Items[0].Labels[LabelWorkload] != "web" {
+		t.Fatalf("got forensic pods %+v, want one for the Deployment", forensicPods.Items)
+	}
+}
+
+func TestWorkloadLabel(t *testing.T) {
+	w := workloadIdentity{Name: "a-very-long-deployment-name-that-goes-past-the-label-value-limit-x"}
+	if got := w.Label(); len(got) > 63 || got[len(got)-1] == '-' {
+		t.Errorf("label value %q is not valid", got)
+	}
+}
@@ -30,6 +30,7 @@ echo "🛠️ Deploying Controller..."
 sed "s|image: controller:.*|image: $IMAGE_NAME|g" deploy/manager.yaml > deploy/manager-e2e.yaml
 sed -i.bak "s|imagePullPolicy: IfNotPresent|imagePullPolicy: Never|g" deploy/manager-e2e.yaml
 
+kubectl --context "kind-$CLUSTER_NAME" apply -f config/crd/bases
 kubectl --context "kind-$CLUSTER_NAME" apply -f config/rbac/rbac.yaml
 kubectl --context "kind-$CLUSTER_NAME" apply -f deploy/manager-e2e.yaml
 
@@ -69,6 +70,18 @@ else
     exit 1
 fi
 
+# Check ForensicCase
+echo "🗂️  Checking ForensicCase..."
+CASE_NAME=$(kubectl --context "kind-$CLUSTER_NAME" get pod -n "$NAMESPACE" "$FORENSIC_POD" -o jsonpath="{.metadata.labels.forensic\.io/case}")
+CASE_EXIT_CODE=$(kubectl --context "kind-$CLUSTER_NAME" get forensiccase -n "$NAMESPACE" "$CASE_NAME" -o jsonpath="{.spec.exitCode}")
+
+if [ "$CASE_EXIT_CODE" == "1" ]; then
+    echo "✅ ForensicCase Verified: $CASE_NAME"
+else
+    echo "❌ Test Failed: Expected ForensicCase with Exit Code 1, got '$CASE_EXIT_CODE' (case '$CASE_NAME')"
+    exit 1
+fi
+
 echo "🎉 E2E Test Passed!"
 
 # Cleanup (Optional)
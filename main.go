@@ -5,6 +5,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
@@ -26,12 +28,18 @@ import (
 
 	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 
+	forensicv1alpha1 "kube-forensics-controller/api/v1alpha1"
+
 	"kube-forensics-controller/controllers"
 
 	"kube-forensics-controller/pkg/checkpoint"
 
+	"kube-forensics-controller/pkg/collector"
+
 	"kube-forensics-controller/pkg/storage"
 
+	"kube-forensics-controller/pkg/version"
+
 	"k8s.io/client-go/kubernetes"
 
 	"strings"
@@ -53,6 +61,8 @@ func init() {
 
 	utilruntime.Must(snapshotv1.AddToScheme(scheme))
 
+	utilruntime.Must(forensicv1alpha1.AddToScheme(scheme))
+
 	//+kubebuilder:scaffold:scheme
 
 }
@@ -79,8 +89,18 @@ func main() {
 
 	var forensicTTL string
 
+	var artifactRetention string
+
 	var maxLogSize int64
 
+	var logTruncation string
+
+	var logHeadSize int64
+
+	var largeLogEvidence string
+
+	var evidenceStorageClass string
+
 	var ignoreNamespaces string
 
 	var watchNamespaces string
@@ -89,18 +109,44 @@ func main() {
 
 	var enableCheckpointing bool
 
+	var enableSnapshots bool
+
+	var enableToolkit bool
+
 	var rateLimitWindow string
 
+	var crashLoopMinRestarts int
+
+	var restartDeltaThreshold int
+
+	var restartRateThreshold int
+
+	var restartRateWindow string
+
+	var captureStartupFailures bool
+
+	var unschedulableThreshold string
+
 	var enableDatadogProfiling bool
 
 	var datadogServiceName string
 
-	var s3Bucket string
-
-	var s3Region string
+	var storageConfig storage.Config
 
 	var collectorImage string
 
+	var signingKeySecret string
+
+	var signingKeyNamespace string
+
+	var rulesConfigMap string
+
+	var rulesNamespace string
+
+	var clusterName string
+
+	var storageKeyTemplate string
+
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -115,8 +161,18 @@ func main() {
 
 	flag.StringVar(&forensicTTL, "forensic-ttl", "24h", "Time to live for forensic pods (e.g., 24h, 30m).")
 
+	flag.StringVar(&artifactRetention, "artifact-retention", "0", "How long exported artifacts are kept in storage (e.g., 720h). 0 keeps them forever.")
+
 	flag.Int64Var(&maxLogSize, "max-log-size", 500*1024, "Maximum log size to capture in bytes.")
 
+	flag.StringVar(&logTruncation, "log-truncation", controllers.LogTruncationHead, "How to truncate logs larger than max-log-size: 'head' keeps the start, 'head-tail' keeps the start and the end.")
+
+	flag.Int64Var(&logHeadSize, "log-head-size", 64*1024, "Bytes kept from the start of the log in head-tail mode. The rest of max-log-size is kept from the end.")
+
+	flag.StringVar(&largeLogEvidence, "large-log-evidence", controllers.EvidenceChunked, "Where logs that do not fit one ConfigMap are kept: 'chunked' ConfigMaps or an evidence 'pvc'.")
+
+	flag.StringVar(&evidenceStorageClass, "evidence-storage-class", "", "StorageClass of evidence PVCs with --large-log-evidence=pvc. Empty uses the default class.")
+
 	flag.StringVar(&ignoreNamespaces, "ignore-namespaces", "kube-system,kube-public", "Comma-separated list of namespaces to ignore.")
 
 	flag.StringVar(&watchNamespaces, "watch-namespaces", "", "Comma-separated list of namespaces to watch. If empty, watches all (except ignored).")
@@ -125,15 +181,43 @@ func main() {
 
 	flag.BoolVar(&enableCheckpointing, "enable-checkpointing", false, "Enable experimental Container Checkpointing (requires Kubelet feature gate).")
 
+	flag.BoolVar(&enableSnapshots, "enable-snapshots", true, "Enable VolumeSnapshots of the crashed pod's PVCs. Can be overridden per ForensicPolicy.")
+
+	flag.BoolVar(&enableToolkit, "enable-toolkit", true, "Inject the busybox toolkit into forensic pods. Can be overridden per ForensicPolicy.")
+
 	flag.StringVar(&rateLimitWindow, "rate-limit-window", "1h", "Window for deduplicating similar crashes (e.g., 1h, 10m).")
 
+	flag.IntVar(&crashLoopMinRestarts, "crashloop-min-restarts", 1, "Restarts after which a container waiting in CrashLoopBackOff is captured, whatever its exit code. 0 disables.")
+
+	flag.IntVar(&restartDeltaThreshold, "restart-delta-threshold", 0, "Capture a container after this many restarts since its last capture, whatever its exit code. 0 disables.")
+
+	flag.IntVar(&restartRateThreshold, "restart-rate-threshold", 0, "Capture a container restarting this many times within --restart-rate-window. 0 disables.")
+
+	flag.StringVar(&restartRateWindow, "restart-rate-window", controllers.DefaultRestartRateWindow.String(), "Window of --restart-rate-threshold (e.g., 10m).")
+
+	flag.BoolVar(&captureStartupFailures, "capture-startup-failures", false, "Record pods that never start (ImagePullBackOff, ErrImagePull, CreateContainerConfigError, Unschedulable) in a ForensicCase, without a forensic pod.")
+
+	flag.StringVar(&unschedulableThreshold, "unschedulable-threshold", controllers.DefaultUnschedulableThreshold.String(), "How long a pod may stay unschedulable before it is captured as a startup failure (e.g., 5m).")
+
 	flag.StringVar(&collectorImage, "collector-image", "amzacdocker/kube-forensics-controller:v0.2.2", "Image to use for the collector job.")
 
-	// S3 Flags
+	// Signing Flags
+	flag.StringVar(&signingKeySecret, "signing-key-secret", "kube-forensics-signing-key", "Secret holding the ed25519 key used to sign evidence manifests. Created if missing. Empty disables signing.")
+
+	flag.StringVar(&signingKeyNamespace, "signing-key-namespace", "", "Namespace of the signing key Secret. Defaults to the controller's namespace (POD_NAMESPACE).")
+
+	// Custom Rule Flags
+	flag.StringVar(&rulesConfigMap, "rules-configmap", "", "ConfigMap holding custom log rules, reloaded on every change. Empty disables custom rules.")
+
+	flag.StringVar(&rulesNamespace, "rules-namespace", "", "Namespace of the rules ConfigMap. Defaults to the controller's namespace (POD_NAMESPACE).")
+
+	// Storage Flags (S3 / GCS / Azure / filesystem)
+
+	storageConfig.RegisterFlags(flag.CommandLine)
 
-	flag.StringVar(&s3Bucket, "s3-bucket", "", "S3 Bucket for exporting forensic artifacts (logs).")
+	flag.StringVar(&storageKeyTemplate, "storage-key-template", controllers.DefaultStorageKeyTemplate, "Go template of the storage prefix of a capture. Fields: .Cluster, .Namespace, .Pod, .UID, .Timestamp, .Date.")
 
-	flag.StringVar(&s3Region, "s3-region", "us-east-1", "AWS Region for S3.")
+	flag.StringVar(&clusterName, "cluster-name", "", "Cluster name recorded in the metadata of exported artifacts and available to --storage-key-template.")
 
 	// Datadog Flags
 
@@ -152,29 +236,45 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	// Initialize S3 Exporter
+	setupLog.Info("kube-forensics-controller", "version", version.Version)
 
-	var provider storage.Provider
+	// Initialize Storage Exporter
 
-	if s3Bucket != "" {
+	if err := storageConfig.LoadEncryptionRecipients(); err != nil {
 
-		setupLog.Info("Initializing S3 Provider", "bucket", s3Bucket, "region", s3Region)
+		setupLog.Error(err, "Failed to load encryption recipients")
 
-		s3Exp, err := storage.NewS3Provider(context.TODO(), s3Bucket, s3Region)
+		os.Exit(1)
 
-		if err != nil {
+	}
 
-			setupLog.Error(err, "Failed to initialize S3 Provider")
+	if storageConfig.Enabled() {
 
-			os.Exit(1)
+		setupLog.Info("Initializing storage provider", "backend", storageConfig.Backend, "encrypted", storageConfig.EncryptionRecipients != "")
 
-		}
+	}
 
-		provider = s3Exp
+	provider, err := storage.New(context.TODO(), storageConfig)
 
-	} else {
+	if err != nil {
 
-		provider = &storage.NoOpProvider{}
+		setupLog.Error(err, "Failed to initialize storage provider")
+
+		os.Exit(1)
+
+	}
+
+	// Object Lock can only be enabled when a bucket is created, so check it before the first crash
+
+	if locker, ok := storage.AsObjectLocker(provider); ok && (storageConfig.S3ObjectLockMode != "" || storageConfig.S3LegalHold) {
+
+		if err := locker.CheckObjectLock(context.TODO()); err != nil {
+
+			setupLog.Error(err, "S3 Object Lock is not usable", "bucket", storageConfig.S3Bucket)
+
+			os.Exit(1)
+
+		}
 
 	}
 
@@ -216,6 +316,37 @@ func main() {
 
 	}
 
+	// The signing key and the rules live in the controller's own namespace.
+	// Guessing "default" would write the private key where it does not belong.
+
+	if signingKeyNamespace == "" {
+
+		signingKeyNamespace = os.Getenv("POD_NAMESPACE")
+
+	}
+
+	if signingKeySecret != "" && signingKeyNamespace == "" {
+
+		setupLog.Error(fmt.Errorf("POD_NAMESPACE is not set"), "set --signing-key-namespace, or --signing-key-secret= to disable signing")
+
+		os.Exit(1)
+
+	}
+
+	if rulesNamespace == "" {
+
+		rulesNamespace = os.Getenv("POD_NAMESPACE")
+
+	}
+
+	if rulesConfigMap != "" && rulesNamespace == "" {
+
+		setupLog.Error(fmt.Errorf("POD_NAMESPACE is not set"), "set --rules-namespace")
+
+		os.Exit(1)
+
+	}
+
 	// Parse TTL
 
 	ttlDuration, err := time.ParseDuration(forensicTTL)
@@ -228,6 +359,70 @@ func main() {
 
 	}
 
+	retentionDuration, err := time.ParseDuration(artifactRetention)
+
+	if err != nil {
+
+		setupLog.Error(err, "unable to parse artifact-retention")
+
+		os.Exit(1)
+
+	}
+
+	keyTemplate, err := controllers.ParseStorageKeyTemplate(storageKeyTemplate)
+
+	if err != nil {
+
+		setupLog.Error(err, "invalid storage-key-template")
+
+		os.Exit(1)
+
+	}
+
+	restartRateDuration, err := time.ParseDuration(restartRateWindow)
+
+	if err != nil {
+
+		setupLog.Error(err, "unable to parse restart-rate-window")
+
+		os.Exit(1)
+
+	}
+
+	if err := controllers.ValidateRestartCriteria(int32(crashLoopMinRestarts), int32(restartDeltaThreshold), int32(restartRateThreshold), restartRateDuration); err != nil {
+
+		setupLog.Error(err, "invalid restart crash criteria")
+
+		os.Exit(1)
+
+	}
+
+	unschedulableDuration, err := time.ParseDuration(unschedulableThreshold)
+
+	if err != nil {
+
+		setupLog.Error(err, "unable to parse unschedulable-threshold")
+
+		os.Exit(1)
+
+	}
+
+	if err := controllers.ValidateLogTruncation(logTruncation, maxLogSize, logHeadSize); err != nil {
+
+		setupLog.Error(err, "invalid log truncation settings")
+
+		os.Exit(1)
+
+	}
+
+	if largeLogEvidence != controllers.EvidenceChunked && largeLogEvidence != controllers.EvidencePVC {
+
+		setupLog.Error(fmt.Errorf("unknown mode %q", largeLogEvidence), "invalid large-log-evidence")
+
+		os.Exit(1)
+
+	}
+
 	// Parse Rate Limit Window
 
 	rateLimitDuration, err := time.ParseDuration(rateLimitWindow)
@@ -300,8 +495,18 @@ func main() {
 
 		ForensicTTL: ttlDuration,
 
+		ArtifactRetention: retentionDuration,
+
 		MaxLogSizeBytes: maxLogSize,
 
+		LogTruncationMode: logTruncation,
+
+		LogHeadBytes: logHeadSize,
+
+		LargeLogEvidence: largeLogEvidence,
+
+		EvidenceStorageClass: evidenceStorageClass,
+
 		IgnoreNamespaces: ignoreList,
 
 		WatchNamespaces: watchList,
@@ -312,9 +517,37 @@ func main() {
 
 		RateLimitWindow: rateLimitDuration,
 
-		S3Bucket: s3Bucket,
+		CrashLoopMinRestarts: int32(crashLoopMinRestarts),
+
+		RestartDeltaThreshold: int32(restartDeltaThreshold),
+
+		RestartRateThreshold: int32(restartRateThreshold),
+
+		RestartRateWindow: restartRateDuration,
+
+		CaptureStartupFailures: captureStartupFailures,
+
+		UnschedulableThreshold: unschedulableDuration,
+
+		EnableSnapshots: enableSnapshots,
+
+		EnableExport: true,
+
+		EnableToolkit: enableToolkit,
+
+		Storage: storageConfig,
 
-		S3Region: s3Region,
+		StorageKeyTemplate: keyTemplate,
+
+		ClusterName: clusterName,
+
+		SigningKeySecret: signingKeySecret,
+
+		SigningKeyNamespace: signingKeyNamespace,
+
+		RulesConfigMap: rulesConfigMap,
+
+		RulesNamespace: rulesNamespace,
 
 		Image: collectorImage,
 	}
@@ -397,45 +630,63 @@ func runCollector() {
 
 	var file string
 
-	var bucket string
-
-	var region string
-
 	var key string
 
+	var storageConfig storage.Config
+
 	fs := flag.NewFlagSet("collector", flag.ExitOnError)
 
 	fs.StringVar(&file, "file", "", "Path to file to upload")
 
-	fs.StringVar(&bucket, "s3-bucket", "", "S3 Bucket")
+	fs.StringVar(&key, "key", "", "Object key")
+
+	fs.StringVar(&key, "s3-key", "", "Object key (deprecated alias of --key)")
 
-	fs.StringVar(&region, "s3-region", "us-east-1", "S3 Region")
+	var metadata string
 
-	fs.StringVar(&key, "s3-key", "", "S3 Key")
+	fs.StringVar(&metadata, "metadata", "", "Object metadata, URL query encoded (k=v&k2=v2)")
+
+	storageConfig.RegisterFlags(fs)
 
 	fs.Parse(os.Args[2:])
 
-	if file == "" || bucket == "" || key == "" {
+	if file == "" || key == "" || !storageConfig.Enabled() {
 
-		fmt.Println("Usage: collector --file=... --s3-bucket=... --s3-key=...")
+		fmt.Println("Usage: collector --file=... --key=... [--storage-backend=s3|gcs|azblob|filesystem] --s3-bucket=...|--gcs-bucket=...|--azure-container=...|--storage-path=...")
 
 		os.Exit(1)
 
 	}
 
-	fmt.Printf("Starting collector for %s -> s3://%s/%s\n", file, bucket, key)
+	fmt.Printf("Starting collector for %s -> %s:%s\n", file, storageConfig.Backend, key)
+
+	// Cancel on SIGTERM (e.g. Job deletion) so a multipart upload is aborted
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+
+	defer stop()
+
+	provider, err := storage.New(ctx, storageConfig)
+
+	if err != nil {
+
+		fmt.Printf("Error initializing storage: %v\n", err)
+
+		os.Exit(1)
+
+	}
 
-	provider, err := storage.NewS3Provider(context.Background(), bucket, region)
+	meta, err := storage.ParseMetadata(metadata)
 
 	if err != nil {
 
-		fmt.Printf("Error initializing S3: %v\n", err)
+		fmt.Printf("Invalid --metadata: %v\n", err)
 
 		os.Exit(1)
 
 	}
 
-	url, err := provider.UploadFile(context.Background(), key, file)
+	url, sum, err := collector.Upload(ctx, provider, key, file, meta, os.Stdout)
 
 	if err != nil {
 
@@ -445,7 +696,7 @@ func runCollector() {
 
 	}
 
-	fmt.Printf("Successfully uploaded: %s\n", url)
+	fmt.Printf("Successfully uploaded: %s (sha256 %s)\n", url, sum)
 
 	// Cleanup
 
@@ -0,0 +1,139 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ManifestName is the first entry of every bundle
+const ManifestName = "manifest.json"
+
+// Manifest describes the contents of an evidence bundle
+type Manifest struct {
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"createdAt"`
+	SourcePod PodRef     `json:"sourcePod"`
+	Workloads []Workload `json:"workloads,omitempty"`
+	Files     []File     `json:"files"`
+	// Errors lists the evidence that could not be collected
+	Errors []string `json:"errors,omitempty"`
+}
+
+// PodRef identifies the crashed pod
+type PodRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	UID       string `json:"uid"`
+	Node      string `json:"node,omitempty"`
+}
+
+// Workload is an owner of the crashed pod, closest owner first
+type Workload struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Revision string `json:"revision,omitempty"`
+	File     string `json:"file,omitempty"`
+}
+
+// File is a single entry of the bundle
+type File struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// Bundle collects files in memory and writes them as a tar.gz
+type Bundle struct {
+	Manifest Manifest
+	data     map[string][]byte
+}
+
+// New creates an empty bundle for a pod
+func New(pod PodRef) *Bundle {
+	return &Bundle{
+		Manifest: Manifest{
+			Version:   1,
+			CreatedAt: time.Now().UTC(),
+			SourcePod: pod,
+		},
+		data: make(map[string][]byte),
+	}
+}
+
+// Add stores a file and records it in the manifest
+func (b *Bundle) Add(name string, description string, data []byte) {
+	hash := sha256.Sum256(data)
+	b.data[name] = data
+	b.Manifest.Files = append(b.Manifest.Files, File{
+		Name:        name,
+		Description: description,
+		Size:        int64(len(data)),
+		SHA256:      hex.EncodeToString(hash[:]),
+	})
+}
+
+// AddYAML stores an object as YAML
+func (b *Bundle) AddYAML(name string, description string, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	b.Add(name, description, data)
+	return nil
+}
+
+// Error records evidence that could not be collected. The bundle is still written.
+func (b *Bundle) Error(msg string) {
+	b.Manifest.Errors = append(b.Manifest.Errors, msg)
+}
+
+// Bytes writes the bundle as a tar.gz, manifest first, files in the order they were added
+func (b *Bundle) Bytes() ([]byte, error) {
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	write := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: b.Manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := write(ManifestName, manifest); err != nil {
+		return nil, err
+	}
+	for _, f := range b.Manifest.Files {
+		if err := write(f.Name, b.data[f.Name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,6 +1,10 @@
 package collector
 
 import (
+	"path/filepath"
+
+	"kube-forensics-controller/pkg/storage"
+
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -11,9 +15,9 @@ type JobConfig struct {
 	Namespace      string
 	NodeName       string
 	CheckpointPath string
-	S3Bucket       string
-	S3Region       string
-	S3Key          string
+	Storage        storage.Config
+	Key            string
+	Metadata       storage.Metadata
 	Image          string
 	OwnerReference metav1.OwnerReference
 }
@@ -38,6 +42,9 @@ func BuildJob(cfg JobConfig) *batchv1.Job {
 		Spec: batchv1.JobSpec{
 			TTLSecondsAfterFinished: func(i int32) *int32 { return &i }(300), // Cleanup after 5 mins
 			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: storageLabels(cfg.Storage),
+				},
 				Spec: corev1.PodSpec{
 					NodeName:           cfg.NodeName, // Pin to the node where the file is
 					RestartPolicy:      corev1.RestartPolicyNever,
@@ -46,31 +53,31 @@ func BuildJob(cfg JobConfig) *batchv1.Job {
 						{
 							Name:  "collector",
 							Image: cfg.Image, // Use the controller image which has the 'collector' subcommand
-							Command: []string{
+							Command: append([]string{
 								"/manager",
 								"collector",
 								"--file=" + cfg.CheckpointPath,
-								"--s3-bucket=" + cfg.S3Bucket,
-								"--s3-region=" + cfg.S3Region,
-								"--s3-key=" + cfg.S3Key,
-							},
+								"--key=" + cfg.Key,
+								"--metadata=" + cfg.Metadata.Encode(),
+							}, cfg.Storage.Args()...),
+							Env: storageEnv(cfg.Storage),
 							SecurityContext: &corev1.SecurityContext{
 								RunAsUser:  &rootUser,                              // Checkpoints are usually root:root
 								Privileged: func(b bool) *bool { return &b }(true), // Likely needed for hostPath read
 							},
-							VolumeMounts: []corev1.VolumeMount{
+							VolumeMounts: append([]corev1.VolumeMount{
 								{
 									Name:      "checkpoint-file",
 									MountPath: cfg.CheckpointPath, // Mount exact file path
 									ReadOnly:  true,
 								},
-							},
-							// Inject AWS Creds if present in Env (handled by SA/IRSA usually)
+							}, storageMounts(cfg.Storage)...),
+							// Inject Cloud Creds if present in Env (handled by SA/IRSA/Workload Identity usually)
 							// If we rely on Env vars in the controller, we should propagate them here.
-							// For MVP, we assume IRSA or Node Role.
+							// For MVP, we assume IRSA, Workload Identity or Node Role.
 						},
 					},
-					Volumes: []corev1.Volume{
+					Volumes: append([]corev1.Volume{
 						{
 							Name: "checkpoint-file",
 							VolumeSource: corev1.VolumeSource{
@@ -80,10 +87,95 @@ func BuildJob(cfg JobConfig) *batchv1.Job {
 								},
 							},
 						},
-					},
+					}, storageVolumes(cfg.Storage)...),
 				},
 			},
 		},
 	}
 	return job
 }
+
+// storageEnv passes backend credentials that must not appear in the Job args
+func storageEnv(cfg storage.Config) []corev1.EnvVar {
+	switch cfg.Backend {
+	case storage.BackendAzure:
+		if cfg.AzureConnectionStringSecret != "" {
+			return []corev1.EnvVar{secretEnv(storage.AzureConnectionStringEnv, cfg.AzureConnectionStringSecret, "connectionString")}
+		}
+	case storage.BackendS3, "":
+		if cfg.S3CredentialsSecret != "" {
+			return []corev1.EnvVar{
+				secretEnv(storage.S3AccessKeyIDEnv, cfg.S3CredentialsSecret, "accessKeyID"),
+				secretEnv(storage.S3SecretAccessKeyEnv, cfg.S3CredentialsSecret, "secretAccessKey"),
+			}
+		}
+	}
+	return nil
+}
+
+func secretEnv(name, secret, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+// s3CABundle reports whether the S3 CA bundle is mounted into the collector
+func s3CABundle(cfg storage.Config) bool {
+	isS3 := cfg.Backend == storage.BackendS3 || cfg.Backend == ""
+	return isS3 && cfg.S3CABundle != "" && cfg.S3CABundleConfigMap != ""
+}
+
+// storageVolumes holds the CA bundle of an S3-compatible endpoint or the
+// PVC of the filesystem backend
+func storageVolumes(cfg storage.Config) []corev1.Volume {
+	if cfg.Backend == storage.BackendFilesystem && cfg.StoragePVC != "" {
+		return []corev1.Volume{{
+			Name: "storage",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: cfg.StoragePVC},
+			},
+		}}
+	}
+	if !s3CABundle(cfg) {
+		return nil
+	}
+	return []corev1.Volume{{
+		Name: "s3-ca-bundle",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: cfg.S3CABundleConfigMap},
+				Items:                []corev1.KeyToPath{{Key: "ca.crt", Path: filepath.Base(cfg.S3CABundle)}},
+			},
+		},
+	}}
+}
+
+// storageMounts mounts the CA bundle at the path given by --s3-ca-bundle and
+// the filesystem backend PVC at --storage-path
+func storageMounts(cfg storage.Config) []corev1.VolumeMount {
+	if cfg.Backend == storage.BackendFilesystem && cfg.StoragePVC != "" {
+		return []corev1.VolumeMount{{Name: "storage", MountPath: cfg.StoragePath}}
+	}
+	if !s3CABundle(cfg) {
+		return nil
+	}
+	return []corev1.VolumeMount{{
+		Name:      "s3-ca-bundle",
+		MountPath: filepath.Dir(cfg.S3CABundle),
+		ReadOnly:  true,
+	}}
+}
+
+// storageLabels opts the collector pod into the cloud identity webhook where needed
+func storageLabels(cfg storage.Config) map[string]string {
+	if cfg.Backend == storage.BackendAzure && cfg.AzureConnectionStringSecret == "" {
+		return map[string]string{"azure.workload.identity/use": "true"}
+	}
+	return nil
+}
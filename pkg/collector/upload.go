@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kube-forensics-controller/pkg/storage"
+)
+
+// Upload streams a file to storage, hashing it in the same pass, then stores
+// the hash next to it as <key>.sha256 in sha256sum format. Progress is
+// written to out. The hash is only known at the end, so it is recorded in the
+// metadata of the .sha256 object.
+func Upload(ctx context.Context, provider storage.Provider, key string, path string, meta storage.Metadata, out io.Writer) (string, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.New()
+	progress := &progressReader{r: file, total: info.Size(), out: out, start: time.Now()}
+	url, err := provider.UploadStream(ctx, key, io.TeeReader(progress, hash), meta)
+	if err != nil {
+		return "", "", err
+	}
+	progress.report(true)
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(key))
+	if _, err := provider.Upload(ctx, key+".sha256", []byte(line), meta.With(storage.MetaSHA256, sum)); err != nil {
+		return url, sum, fmt.Errorf("uploading hash: %w", err)
+	}
+	return url, sum, nil
+}
+
+// progressReader reports every 5% of the file
+type progressReader struct {
+	r        io.Reader
+	total    int64
+	read     int64
+	reported int64
+	out      io.Writer
+	start    time.Time
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	p.report(false)
+	return n, err
+}
+
+func (p *progressReader) report(final bool) {
+	step := p.total / 20
+	if final && p.read == p.reported && p.read > 0 {
+		return // Already reported
+	}
+	if !final && (step == 0 || p.read-p.reported < step) {
+		return
+	}
+	p.reported = p.read
+
+	const mib = 1024 * 1024
+	elapsed := time.Since(p.start).Seconds()
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(p.read) / mib / elapsed
+	}
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(p.read) * 100 / float64(p.total)
+	}
+	fmt.Fprintf(p.out, "Uploaded %.1f / %.1f MiB (%.0f%%, %.1f MiB/s)\n", float64(p.read)/mib, float64(p.total)/mib, percent, rate)
+}
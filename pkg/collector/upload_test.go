@@ -0,0 +1,79 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kube-forensics-controller/pkg/storage"
+)
+
+func TestUploadHashesInOnePass(t *testing.T) {
+	root := t.TempDir()
+	provider, err := storage.NewFilesystemProvider(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("checkpoint-data-"), 64*1024) // 1 MiB
+	src := filepath.Join(t.TempDir(), "checkpoint.tar")
+	if err := os.WriteFile(src, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	url, sum, err := Upload(context.Background(), provider, "prod/web/20260102-100405/checkpoint.tar", src, storage.Metadata{storage.MetaPod: "web"}, &out)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	want := sha256.Sum256(data)
+	if sum != hex.EncodeToString(want[:]) {
+		t.Errorf("got hash %s, want %x", sum, want)
+	}
+	if !strings.HasSuffix(url, "/prod/web/20260102-100405/checkpoint.tar") {
+		t.Errorf("unexpected URL %s", url)
+	}
+
+	uploaded, err := os.ReadFile(filepath.Join(root, "prod/web/20260102-100405/checkpoint.tar"))
+	if err != nil || !bytes.Equal(uploaded, data) {
+		t.Fatalf("uploaded content differs (%v)", err)
+	}
+	hashFile, err := os.ReadFile(filepath.Join(root, "prod/web/20260102-100405/checkpoint.tar.sha256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(hashFile) != sum+"  checkpoint.tar\n" {
+		t.Errorf("unexpected hash file %q", hashFile)
+	}
+	meta, err := os.ReadFile(filepath.Join(root, "prod/web/20260102-100405/.checkpoint.tar.sha256.metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(meta), `"sha256": "`+sum+`"`) || !strings.Contains(string(meta), `"source-pod": "web"`) {
+		t.Errorf("unexpected hash metadata %s", meta)
+	}
+
+	// At most one line per 5%, ending at 100% once
+	if lines := strings.Count(out.String(), "\n"); lines < 5 || lines > 21 {
+		t.Errorf("expected 5 to 21 progress lines, got %d:\n%s", lines, out.String())
+	}
+	if strings.Count(out.String(), "(100%") != 1 {
+		t.Errorf("expected one final progress line:\n%s", out.String())
+	}
+}
+
+func TestUploadMissingFile(t *testing.T) {
+	provider, err := storage.NewFilesystemProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Upload(context.Background(), provider, "prod/web/checkpoint.tar", filepath.Join(t.TempDir(), "missing.tar"), nil, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
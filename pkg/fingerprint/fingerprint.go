@@ -0,0 +1,262 @@
+// Package fingerprint extracts the last exception or panic from a container
+// log and reduces its stack trace to a fingerprint that is stable across
+// builds, restarts and replicas of the same code.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Languages of the stack traces that are recognized
+const (
+	LanguageGo     = "go"
+	LanguageJava   = "java"
+	LanguagePython = "python"
+	LanguageNode   = "node"
+	LanguageDotNet = "dotnet"
+)
+
+// TopFrames is the number of frames, closest to the crash first, that go into the hash
+const TopFrames = 5
+
+// maxMessageLength caps the exception message, which may embed payloads
+const maxMessageLength = 512
+
+// Fingerprint is the last exception or panic found in a log
+type Fingerprint struct {
+	Language string
+	Type     string   // e.g. java.lang.NullPointerException, ValueError, runtime error
+	Message  string   // As logged, capped at 512 bytes
+	Frames   []string // Normalized top frames, closest to the crash first
+	Hash     string   // SHA-256 of the type and frames, empty without frames
+}
+
+var (
+	goPanic       = regexp.MustCompile(`^(panic|fatal error): (.*?)(?: \[recovered\])?$`)
+	goGoroutine   = regexp.MustCompile(`^goroutine \d+ \[`)
+	pyTraceback   = regexp.MustCompile(`^Traceback \(most recent call last\):`)
+	pyFrame       = regexp.MustCompile(`^\s+File "([^"]+)", line \d+, in (.+)$`)
+	atFrame       = regexp.MustCompile(`^\s+at (.+)$`)
+	exceptionLine = regexp.MustCompile(`^([A-Za-z_$][\w$.]*)(?: \[[\w-]+\])?(?::\s?(.*))?$`)
+
+	hexAddress  = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	goroutineID = regexp.MustCompile(`goroutine \d+`)
+	lineNumber  = regexp.MustCompile(`(:line \d+|:\d+(:\d+)?)`)
+)
+
+// Extract finds the last exception or panic in a log. It returns nil when the
+// log holds no stack trace it recognizes.
+func Extract(log string) *Fingerprint {
+	lines := strings.Split(strings.ReplaceAll(log, "\r\n", "\n"), "\n")
+
+	// Parsers report where their trace starts, the last one wins
+	var last *Fingerprint
+	lastAt := -1
+	for _, parse := range []func([]string) (*Fingerprint, int){parseGo, parsePython, parseAt} {
+		if fp, at := parse(lines); fp != nil && at > lastAt {
+			last, lastAt = fp, at
+		}
+	}
+	if last == nil {
+		return nil
+	}
+
+	if len(last.Message) > maxMessageLength {
+		last.Message = last.Message[:maxMessageLength]
+	}
+	if len(last.Frames) > TopFrames {
+		last.Frames = last.Frames[:TopFrames]
+	}
+	if len(last.Frames) > 0 {
+		hash := sha256.Sum256([]byte(last.Type + "\n" + strings.Join(last.Frames, "\n")))
+		last.Hash = hex.EncodeToString(hash[:])
+	}
+	return last
+}
+
+// Normalize removes what differs between two occurrences of the same frame:
+// line and column numbers, memory addresses and goroutine IDs
+func Normalize(frame string) string {
+	frame = hexAddress.ReplaceAllString(frame, "0x?")
+	frame = goroutineID.ReplaceAllString(frame, "goroutine N")
+	frame = lineNumber.ReplaceAllString(frame, "")
+	return strings.TrimSpace(frame)
+}
+
+// parseGo reads the last Go panic or fatal error and the stack of the
+// goroutine that raised it:
+//
+//	panic: runtime error: invalid memory address or nil pointer dereference
+//	[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a2b3c]
+//
+//	goroutine 1 [running]:
+//	main.(*Server).handle(0xc000010000, 0x0)
+//		/app/server.go:42 +0x1d
+func parseGo(lines []string) (*Fingerprint, int) {
+	start := -1
+	var m []string
+	for i, line := range lines {
+		if mm := goPanic.FindStringSubmatch(strings.TrimSpace(line)); mm != nil {
+			start, m = i, mm
+		}
+	}
+	if start < 0 {
+		return nil, -1
+	}
+
+	fp := &Fingerprint{Language: LanguageGo, Type: m[1], Message: m[2]}
+	// panic: runtime error: index out of range [5] with length 3
+	if m[1] == "panic" && strings.HasPrefix(m[2], "runtime error: ") {
+		fp.Type, fp.Message = "runtime error", strings.TrimPrefix(m[2], "runtime error: ")
+	}
+
+	i := start + 1
+	for i < len(lines) && !goGoroutine.MatchString(lines[i]) {
+		i++
+	}
+	for i++; i+1 < len(lines); i += 2 {
+		fn, loc := lines[i], lines[i+1]
+		if fn == "" || strings.HasPrefix(fn, "created by ") || !strings.HasPrefix(loc, "\t") {
+			break
+		}
+		// The panic machinery is the same in every panic
+		if strings.HasPrefix(fn, "panic(") || strings.HasPrefix(fn, "runtime.") {
+			continue
+		}
+		// main.(*Server).handle(0xc000010000, 0x0) -> main.(*Server).handle
+		if j := strings.LastIndex(fn, "("); j > 0 && strings.HasSuffix(fn, ")") {
+			fn = fn[:j]
+		}
+		// /app/server.go:42 +0x1d -> /app/server.go:42
+		loc = strings.TrimSpace(loc)
+		if j := strings.Index(loc, " +0x"); j >= 0 {
+			loc = loc[:j]
+		}
+		fp.Frames = append(fp.Frames, Normalize(fn+" ("+loc+")"))
+	}
+	return fp, start
+}
+
+// parsePython reads the last traceback. Python prints the frame closest to
+// the crash last, so the frames are reversed.
+//
+//	Traceback (most recent call last):
+//	  File "/app/main.py", line 6, in main
+//	    raise ValueError("bad config")
+//	ValueError: bad config
+func parsePython(lines []string) (*Fingerprint, int) {
+	start := -1
+	for i, line := range lines {
+		if pyTraceback.MatchString(strings.TrimSpace(line)) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil, -1
+	}
+
+	fp := &Fingerprint{Language: LanguagePython}
+	var frames []string
+	for _, line := range lines[start+1:] {
+		if m := pyFrame.FindStringSubmatch(line); m != nil {
+			frames = append(frames, Normalize(m[2]+" ("+m[1]+")"))
+			continue
+		}
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		// The first unindented line is the exception
+		if m := exceptionLine.FindStringSubmatch(line); m != nil {
+			fp.Type, fp.Message = m[1], m[2]
+		}
+		break
+	}
+	if fp.Type == "" {
+		return nil, -1
+	}
+	for i := len(frames) - 1; i >= 0; i-- {
+		fp.Frames = append(fp.Frames, frames[i])
+	}
+	return fp, start
+}
+
+// parseAt reads the last "at" stack trace, the format shared by Java,
+// Node.js and .NET. The line before the first frame names the exception;
+// chained causes and inner exceptions are part of the same trace.
+//
+//	Exception in thread "main" java.lang.IllegalStateException: boom
+//		at com.example.App.process(App.java:42)
+//	Caused by: ...
+func parseAt(lines []string) (*Fingerprint, int) {
+	start := -1
+	for i := 1; i < len(lines); i++ {
+		// A frame after a line that is neither a frame nor a cause starts a trace
+		if atFrame.MatchString(lines[i]) && !atFrame.MatchString(lines[i-1]) && !continuation(lines[i-1]) {
+			start = i - 1
+		}
+	}
+	if start < 0 {
+		return nil, -1
+	}
+
+	header := strings.TrimSpace(lines[start])
+	fp := &Fingerprint{Language: LanguageNode}
+	switch {
+	case strings.HasPrefix(header, "Exception in thread "):
+		// Exception in thread "main" java.lang.NullPointerException
+		if j := strings.Index(header[len("Exception in thread \""):], "\" "); j >= 0 {
+			header = header[len("Exception in thread \"")+j+2:]
+		}
+		fp.Language = LanguageJava
+	case strings.HasPrefix(header, "Unhandled exception. "):
+		header = strings.TrimPrefix(header, "Unhandled exception. ")
+		fp.Language = LanguageDotNet
+	case strings.HasPrefix(header, "Uncaught "):
+		header = strings.TrimPrefix(header, "Uncaught ")
+	}
+	// .NET prints inner exceptions on the header line: A: msg ---> B: msg
+	if j := strings.Index(header, " ---> "); j >= 0 {
+		header = header[:j]
+	}
+	m := exceptionLine.FindStringSubmatch(header)
+	if m == nil {
+		return nil, -1
+	}
+	fp.Type, fp.Message = m[1], m[2]
+
+	for _, line := range lines[start+1:] {
+		f := atFrame.FindStringSubmatch(line)
+		if f == nil {
+			break
+		}
+		frame := f[1]
+		switch {
+		case strings.Contains(frame, ".java:") || strings.HasSuffix(frame, "(Native Method)") || strings.HasSuffix(frame, "(Unknown Source)"):
+			fp.Language = LanguageJava
+		case strings.Contains(frame, ":line ") || strings.Contains(frame, ".cs:"):
+			fp.Language = LanguageDotNet
+			// X.Y.Method(String s) in /src/File.cs:line 42 -> X.Y.Method(String s) (/src/File.cs)
+			if j := strings.Index(frame, " in "); j >= 0 {
+				frame = frame[:j] + " (" + frame[j+4:] + ")"
+			}
+		}
+		fp.Frames = append(fp.Frames, Normalize(frame))
+	}
+	if fp.Language == LanguageNode && strings.Contains(fp.Type, ".") {
+		// Frames without a file, e.g. (Native Method) only
+		fp.Language = LanguageJava
+	}
+	return fp, start
+}
+
+// continuation reports whether a line belongs to the trace above it
+func continuation(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "Caused by: ") ||
+		strings.HasPrefix(trimmed, "Suppressed: ") ||
+		strings.HasPrefix(trimmed, "---") ||
+		(strings.HasPrefix(trimmed, "... ") && strings.HasSuffix(trimmed, " more"))
+}
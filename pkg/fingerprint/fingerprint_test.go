@@ -0,0 +1,141 @@
+package fingerprint
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const goPanicLog = `starting server on :8080
+panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a2b3c]
+
+goroutine 1 [running]:
+main.(*Server).handle(0xc000010000, 0x0)
+	/app/server.go:42 +0x1d
+main.main()
+	/app/main.go:12 +0x25
+exit status 2`
+
+const javaLog = `INFO  Starting application
+Exception in thread "main" java.lang.IllegalStateException: Order 4711 has no customer
+	at com.example.orders.OrderService.process(OrderService.java:88)
+	at com.example.orders.OrderService.lambda$run$0(OrderService.java:41)
+	at com.example.App.main(App.java:10)
+Caused by: java.lang.NullPointerException: customer
+	at com.example.orders.Customer.of(Customer.java:12)
+	... 3 more`
+
+const pythonLog = `Loading config from /etc/app/config.yaml
+Traceback (most recent call last):
+  File "/app/main.py", line 10, in <module>
+    main()
+  File "/app/main.py", line 6, in main
+    load(path)
+  File "/app/config.py", line 21, in load
+    raise ValueError("missing key: database")
+ValueError: missing key: database`
+
+const nodeLog = `Application started.
+/app/server.js:12
+    throw new TypeError("Cannot read properties of undefined (reading 'id')");
+    ^
+
+TypeError: Cannot read properties of undefined (reading 'id')
+    at handle (/app/server.js:12:11)
+    at Server.<anonymous> (/app/server.js:30:5)
+    at Server.emit (node:events:517:28)
+
+Node.js v18.19.0`
+
+const dotnetLog = `Unhandled exception. System.InvalidOperationException: Sequence contains no elements
+   at System.Linq.ThrowHelper.ThrowNoElementsException()
+   at Shop.Orders.Checkout(Int32 id) in /src/Orders.cs:line 42
+   at Shop.Program.Main(String[] args) in /src/Program.cs:line 9`
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want Fingerprint
+	}{
+		{"go", goPanicLog, Fingerprint{
+			Language: LanguageGo, Type: "runtime error", Message: "invalid memory address or nil pointer dereference",
+			Frames: []string{"main.(*Server).handle (/app/server.go)", "main.main (/app/main.go)"},
+		}},
+		{"java", javaLog, Fingerprint{
+			Language: LanguageJava, Type: "java.lang.IllegalStateException", Message: "Order 4711 has no customer",
+			Frames: []string{
+				"com.example.orders.OrderService.process(OrderService.java)",
+				"com.example.orders.OrderService.lambda$run$0(OrderService.java)",
+				"com.example.App.main(App.java)",
+			},
+		}},
+		{"python", pythonLog, Fingerprint{
+			Language: LanguagePython, Type: "ValueError", Message: "missing key: database",
+			Frames: []string{"load (/app/config.py)", "main (/app/main.py)", "<module> (/app/main.py)"},
+		}},
+		{"node", nodeLog, Fingerprint{
+			Language: LanguageNode, Type: "TypeError", Message: "Cannot read properties of undefined (reading 'id')",
+			Frames: []string{"handle (/app/server.js)", "Server.<anonymous> (/app/server.js)", "Server.emit (node:events)"},
+		}},
+		{"dotnet", dotnetLog, Fingerprint{
+			Language: LanguageDotNet, Type: "System.InvalidOperationException", Message: "Sequence contains no elements",
+			Frames: []string{
+				"System.Linq.ThrowHelper.ThrowNoElementsException()",
+				"Shop.Orders.Checkout(Int32 id) (/src/Orders.cs)",
+				"Shop.Program.Main(String[] args) (/src/Program.cs)",
+			},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Extract(tt.log)
+			if got == nil {
+				t.Fatal("no fingerprint")
+			}
+			if got.Hash == "" {
+				t.Error("no hash")
+			}
+			got.Hash = ""
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("\n got %+v\nwant %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractIsStable(t *testing.T) {
+	// Another build and another goroutine: only line numbers and addresses differ
+	rebuilt := strings.NewReplacer(
+		"goroutine 1 ", "goroutine 17 ",
+		"0xc000010000", "0xc0000a2000",
+		"server.go:42 +0x1d", "server.go:45 +0x2f",
+	).Replace(goPanicLog)
+	if Extract(goPanicLog).Hash != Extract(rebuilt).Hash {
+		t.Error("the same panic in another build got another hash")
+	}
+
+	// Same exception type, thrown elsewhere
+	elsewhere := strings.Replace(javaLog, "OrderService.process", "InvoiceService.process", 1)
+	if Extract(javaLog).Hash == Extract(elsewhere).Hash {
+		t.Error("exceptions from different frames got the same hash")
+	}
+}
+
+func TestExtractLast(t *testing.T) {
+	fp := Extract(pythonLog + "\n" + nodeLog)
+	if fp == nil || fp.Language != LanguageNode {
+		t.Fatalf("got %+v, want the last trace of the log", fp)
+	}
+
+	if fp := Extract("listening on :8080\nshutting down\n"); fp != nil {
+		t.Errorf("got %+v from a log without a trace", fp)
+	}
+
+	// An exception line without frames is not a trace
+	fp = Extract("java.lang.OutOfMemoryError: Java heap space\nDumping heap to /var/dumps/heap.hprof")
+	if fp != nil {
+		t.Errorf("got %+v from a log without frames", fp)
+	}
+}
@@ -0,0 +1,101 @@
+package kubelet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Client reads resource usage from the Kubelet Summary API
+type Client struct {
+	KubeClient kubernetes.Interface
+	Timeout    time.Duration
+}
+
+// NewClient creates a new Summary API Client
+func NewClient(kubeClient kubernetes.Interface) *Client {
+	return &Client{
+		KubeClient: kubeClient,
+		Timeout:    10 * time.Second,
+	}
+}
+
+// Summary is the part of the kubelet stats summary used by the controller
+type Summary struct {
+	Node NodeStats  `json:"node"`
+	Pods []PodStats `json:"pods"`
+}
+
+// NodeStats holds the node usage the kubelet evicts on
+type NodeStats struct {
+	Memory *MemoryStats `json:"memory,omitempty"`
+	Fs     *FsStats     `json:"fs,omitempty"`
+}
+
+// PodStats holds the usage of one pod
+type PodStats struct {
+	PodRef struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		UID       string `json:"uid"`
+	} `json:"podRef"`
+	Containers       []ContainerStats `json:"containers"`
+	Memory           *MemoryStats     `json:"memory,omitempty"`
+	EphemeralStorage *FsStats         `json:"ephemeral-storage,omitempty"`
+}
+
+// ContainerStats holds the usage of one container
+type ContainerStats struct {
+	Name   string       `json:"name"`
+	Memory *MemoryStats `json:"memory,omitempty"`
+	Rootfs *FsStats     `json:"rootfs,omitempty"`
+	Logs   *FsStats     `json:"logs,omitempty"`
+}
+
+// MemoryStats are memory figures in bytes
+type MemoryStats struct {
+	AvailableBytes  *uint64 `json:"availableBytes,omitempty"`
+	UsageBytes      *uint64 `json:"usageBytes,omitempty"`
+	WorkingSetBytes *uint64 `json:"workingSetBytes,omitempty"`
+}
+
+// FsStats are filesystem figures in bytes
+type FsStats struct {
+	AvailableBytes *uint64 `json:"availableBytes,omitempty"`
+	UsedBytes      *uint64 `json:"usedBytes,omitempty"`
+}
+
+// GetSummary reads the stats summary of a node via the API Server Proxy
+// GET /api/v1/nodes/{node}/proxy/stats/summary
+func (c *Client) GetSummary(ctx context.Context, nodeName string) (*Summary, error) {
+	raw, err := c.KubeClient.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("stats/summary").
+		Timeout(c.Timeout).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary Summary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse stats summary: %v", err)
+	}
+	return &summary, nil
+}
+
+// Pod returns the stats of a pod, or nil if the kubelet no longer reports it
+func (s *Summary) Pod(pod *corev1.Pod) *PodStats {
+	for i := range s.Pods {
+		if s.Pods[i].PodRef.UID == string(pod.UID) {
+			return &s.Pods[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Algorithm is the only signature scheme supported
+const Algorithm = "ed25519"
+
+// Artifact kinds
+const (
+	KindLog        = "log"
+	KindConfigMap  = "configmap"
+	KindSecret     = "secret"
+	KindBundle     = "bundle"
+	KindSnapshot   = "snapshot"
+	KindCheckpoint = "checkpoint"
+)
+
+// Manifest lists every artifact captured for a crash
+type Manifest struct {
+	Version        int        `json:"version"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	Case           string     `json:"case,omitempty"`
+	SourcePod      PodRef     `json:"sourcePod"`
+	CrashSignature string     `json:"crashSignature"`
+	Artifacts      []Artifact `json:"artifacts"`
+}
+
+// PodRef identifies the crashed pod
+type PodRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	UID       string `json:"uid"`
+}
+
+// Artifact is a single piece of evidence. SHA256 is empty for artifacts whose
+// content cannot be hashed by the controller, e.g. volume snapshots.
+type Artifact struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Location string `json:"location,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+}
+
+// Envelope is the signed form of a manifest. The payload is kept as the exact
+// bytes that were signed, so no canonical JSON encoding is needed.
+type Envelope struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyID"`
+	Payload   string `json:"payload"`   // base64 of the manifest JSON
+	Signature string `json:"signature"` // base64 of the ed25519 signature over the payload
+}
+
+// Sign encodes and signs a manifest, returning the envelope as JSON
+func Sign(m *Manifest, key ed25519.PrivateKey) ([]byte, error) {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	env := Envelope{
+		Algorithm: Algorithm,
+		KeyID:     KeyID(key.Public().(ed25519.PublicKey)),
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+	}
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// Verify checks the envelope signature against a public key and returns the manifest
+func Verify(data []byte, pub ed25519.PublicKey) (*Manifest, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	if env.Algorithm != Algorithm {
+		return nil, fmt.Errorf("unsupported algorithm %q", env.Algorithm)
+	}
+	if env.KeyID != KeyID(pub) {
+		return nil, fmt.Errorf("manifest was signed by key %s, not %s", env.KeyID, KeyID(pub))
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(env.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	if !ed25519.Verify(pub, payload, sig) {
+		return nil, fmt.Errorf("signature verification failed")
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Decode returns the manifest of an envelope without checking the signature.
+// Use it only to look up recorded hashes; Verify establishes that they are genuine.
+func Decode(data []byte) (*Manifest, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// KeyID is a short fingerprint of a public key
+func KeyID(pub ed25519.PublicKey) string {
+	hash := sha256.Sum256(pub)
+	return hex.EncodeToString(hash[:8])
+}
+
+// Fingerprint is the full SHA-256 fingerprint of a public key, for pinning
+// the trusted key. KeyID is too short to rule out a forged key.
+func Fingerprint(pub ed25519.PublicKey) string {
+	hash := sha256.Sum256(pub)
+	return hex.EncodeToString(hash[:])
+}
+
+// Hash returns the hex SHA-256 of data
+func Hash(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// HashData hashes the content of a ConfigMap or Secret independently of key
+// order: each key and value is written length-prefixed, keys sorted.
+func HashData(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	var size [8]byte
+	for _, k := range keys {
+		binary.BigEndian.PutUint64(size[:], uint64(len(k)))
+		h.Write(size[:])
+		h.Write([]byte(k))
+		binary.BigEndian.PutUint64(size[:], uint64(len(data[k])))
+		h.Write(size[:])
+		h.Write(data[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GenerateKey creates a new signing key pair, PEM encoded
+func GenerateKey() (privatePEM []byte, publicPEM []byte, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicPEM, err = EncodePublicKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), publicPEM, nil
+}
+
+// EncodePublicKey PEM encodes an ed25519 public key
+func EncodePublicKey(pub ed25519.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// ParsePrivateKey decodes a PEM encoded ed25519 private key
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an ed25519 private key")
+	}
+	return priv, nil
+}
+
+// ParsePublicKey decodes a PEM encoded ed25519 public key
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an ed25519 public key")
+	}
+	return pub, nil
+}
@@ -0,0 +1,103 @@
+package manifest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	privPEM, pubPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	priv, err := ParsePrivateKey(privPEM)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey: %v", err)
+	}
+	pub, err := ParsePublicKey(pubPEM)
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+
+	m := &Manifest{
+		Version:   1,
+		SourcePod: PodRef{Namespace: "default", Name: "web", UID: "uid-1"},
+		Artifacts: []Artifact{{Kind: KindLog, Name: "app.current.log", SHA256: Hash([]byte("panic: boom"))}},
+	}
+	signed, err := Sign(m, priv)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	got, err := Verify(signed, pub)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got.Artifacts[0].SHA256 != m.Artifacts[0].SHA256 {
+		t.Errorf("artifact hash changed in round trip")
+	}
+
+	// A tampered payload must be rejected
+	var env Envelope
+	_ = json.Unmarshal(signed, &env)
+	m.Artifacts[0].SHA256 = Hash([]byte("nothing to see"))
+	payload, _ := json.Marshal(m)
+	env.Payload = base64.StdEncoding.EncodeToString(payload)
+	tampered, _ := json.Marshal(env)
+	if _, err := Verify(tampered, pub); err == nil {
+		t.Errorf("tampered manifest verified")
+	}
+
+	// So must a different key
+	_, otherPEM, _ := GenerateKey()
+	other, _ := ParsePublicKey(otherPEM)
+	if _, err := Verify(signed, other); err == nil {
+		t.Errorf("manifest verified with the wrong key")
+	}
+	if fp := Fingerprint(pub); len(fp) != 64 || fp[:16] != KeyID(pub) || fp == Fingerprint(other) {
+		t.Errorf("fingerprint %s does not identify key %s", fp, KeyID(pub))
+	}
+}
+
+func TestHashDataIgnoresKeyOrder(t *testing.T) {
+	a := HashData(map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+	b := HashData(map[string][]byte{"b": []byte("2"), "a": []byte("1")})
+	if a != b {
+		t.Errorf("hash depends on map order")
+	}
+	// Moving bytes between key and value must change the hash
+	if HashData(map[string][]byte{"ab": []byte("c")}) == HashData(map[string][]byte{"a": []byte("bc")}) {
+		t.Errorf("hash is ambiguous")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	privPEM, _, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := ParsePrivateKey(privPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manifest{
+		Version:   1,
+		Artifacts: []Artifact{{Kind: KindLog, Name: "envoy.previous.log", SHA256: Hash([]byte("upstream reset"))}},
+	}
+	signed, err := Sign(m, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(signed)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(got.Artifacts) != 1 || got.Artifacts[0] != m.Artifacts[0] {
+		t.Errorf("Decode returned %+v, want %+v", got.Artifacts, m.Artifacts)
+	}
+	if _, err := Decode([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid envelope")
+	}
+}
@@ -0,0 +1,169 @@
+// Package rootcause decodes how a container ended, from its exit code,
+// termination reason and log, into a probable cause.
+package rootcause
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Categories decoded from the exit code and termination reason
+const (
+	CategoryOOMKilled            = "OOMKilled"
+	CategoryKilled               = "Killed"
+	CategoryTerminated           = "Terminated"
+	CategorySegmentationFault    = "SegmentationFault"
+	CategoryAborted              = "Aborted"
+	CategorySignal               = "Signal"
+	CategoryCommandNotExecutable = "CommandNotExecutable"
+	CategoryCommandNotFound      = "CommandNotFound"
+	CategoryApplicationError     = "ApplicationError"
+	CategoryCleanExit            = "CleanExit"
+	CategoryEvicted              = "Evicted"
+)
+
+// Categories found in the log
+const (
+	CategoryJVMOutOfMemory    = "JVMOutOfMemory"
+	CategoryMissingEnvVar     = "MissingEnvVar"
+	CategoryConnectionRefused = "ConnectionRefused"
+	CategoryConnectionTimeout = "ConnectionTimeout"
+	CategoryDNSFailure        = "DNSFailure"
+	CategoryPermissionDenied  = "PermissionDenied"
+)
+
+// maxEvidenceLength caps the log line quoted as evidence
+const maxEvidenceLength = 256
+
+// Input is how a container instance ended
+type Input struct {
+	ExitCode int32
+	Reason   string // Termination reason, e.g. Error, OOMKilled, StartError, or Evicted
+	Message  string // Termination message
+	Log      string
+}
+
+// Cause is the probable cause of a crash
+type Cause struct {
+	Category    string
+	Signal      string // Signal encoded in the exit code, e.g. SIGKILL for 137
+	Description string
+	Evidence    string // Log line the category was found in, if any
+}
+
+// Message describes the cause for an event
+func (c Cause) Message() string {
+	msg := fmt.Sprintf("Probable cause: %s (%s)", c.Description, c.Category)
+	if c.Evidence != "" {
+		msg += fmt.Sprintf(`: "%s"`, c.Evidence)
+	}
+	return msg
+}
+
+// logRule maps a log line to a category
+type logRule struct {
+	Category    string
+	Description string
+	Pattern     *regexp.Regexp
+}
+
+// logRules are checked in order against each line, most specific first
+var logRules = []logRule{
+	{CategoryJVMOutOfMemory, "The JVM ran out of memory",
+		regexp.MustCompile(`java\.lang\.OutOfMemoryError`)},
+	{CategoryMissingEnvVar, "A required environment variable is not set",
+		regexp.MustCompile(`(?i:(environment variable|env var)\b.*\b(not set|missing|required|undefined|empty)|missing (required )?(environment|env) var)|\b[A-Z][A-Z0-9_]{2,}\b( is)? (not set|must be set|is required|undefined)|KeyError: '[A-Z][A-Z0-9_]+'`)},
+	{CategoryDNSFailure, "A host name could not be resolved",
+		regexp.MustCompile(`(?i)no such host|name or service not known|temporary failure in name resolution|could not resolve host|unknownhostexception|getaddrinfo (enotfound|eai_again)|nodename nor servname`)},
+	{CategoryConnectionRefused, "A dependency refused the connection",
+		regexp.MustCompile(`(?i)connection refused|econnrefused`)},
+	{CategoryConnectionTimeout, "A connection to a dependency timed out",
+		regexp.MustCompile(`(?i)(connection|connect|dial tcp).*\btimed? ?out\b|etimedout|i/o timeout`)},
+	{CategoryPermissionDenied, "Access was denied by file permissions or credentials",
+		regexp.MustCompile(`(?i)permission denied|eacces|accessdeniedexception|operation not permitted`)},
+}
+
+// signals names the signals a container commonly dies of, by number
+var signals = map[int32]string{
+	1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 4: "SIGILL", 5: "SIGTRAP", 6: "SIGABRT",
+	7: "SIGBUS", 8: "SIGFPE", 9: "SIGKILL", 11: "SIGSEGV", 13: "SIGPIPE", 15: "SIGTERM",
+}
+
+// Classify finds the probable cause of a crash. The exit code and reason give
+// the category; a log rule refines it, unless the exit code already tells
+// what happened, e.g. an OOM kill or a segmentation fault.
+func Classify(in Input) Cause {
+	c := exitCause(in)
+	switch c.Category {
+	case CategoryOOMKilled, CategoryEvicted, CategorySegmentationFault, CategoryAborted, CategoryCommandNotFound, CategoryCommandNotExecutable:
+		return c
+	}
+	if rule, line := matchLog(in.Message + "\n" + in.Log); rule != nil {
+		c.Category, c.Description, c.Evidence = rule.Category, rule.Description, line
+	}
+	return c
+}
+
+// exitCause decodes the exit code and termination reason
+func exitCause(in Input) Cause {
+	c := Cause{}
+	if in.ExitCode > 128 {
+		c.Signal = signals[in.ExitCode-128]
+		if c.Signal == "" {
+			c.Signal = fmt.Sprintf("signal %d", in.ExitCode-128)
+		}
+	}
+
+	switch {
+	case in.Reason == "OOMKilled":
+		c.Category, c.Description = CategoryOOMKilled, "The container exceeded its memory limit and was killed by the OOM killer"
+	case in.Reason == "Evicted":
+		c.Category, c.Description = CategoryEvicted, "The kubelet evicted the pod under node pressure"
+	case in.Reason == "StartError" || in.Reason == "ContainerCannotRun":
+		// The runtime could not start the command, e.g. exec: "/app": no such file or directory
+		c.Category, c.Description = CategoryCommandNotFound, "The container command could not be started"
+		if strings.Contains(strings.ToLower(in.Message), "permission denied") {
+			c.Category = CategoryCommandNotExecutable
+		}
+	case in.ExitCode == 137:
+		c.Category, c.Description = CategoryKilled, "The container was killed with SIGKILL, e.g. after a failed liveness probe or shutdown grace period"
+	case in.ExitCode == 143:
+		c.Category, c.Description = CategoryTerminated, "The container exited on SIGTERM, e.g. on shutdown or a failed liveness probe"
+	case in.ExitCode == 139:
+		c.Category, c.Description = CategorySegmentationFault, "The process accessed invalid memory"
+	case in.ExitCode == 134:
+		c.Category, c.Description = CategoryAborted, "The process aborted, e.g. on a failed assertion or a runtime abort"
+	case in.ExitCode == 126:
+		c.Category, c.Description = CategoryCommandNotExecutable, "The container command exists but is not executable"
+	case in.ExitCode == 127:
+		c.Category, c.Description = CategoryCommandNotFound, "The container command was not found"
+	case in.ExitCode > 128:
+		c.Category, c.Description = CategorySignal, fmt.Sprintf("The process was killed by %s", c.Signal)
+	case in.ExitCode == 0:
+		c.Category, c.Description = CategoryCleanExit, "The container exited cleanly and was restarted"
+	default:
+		c.Category, c.Description = CategoryApplicationError, fmt.Sprintf("The application exited with code %d", in.ExitCode)
+	}
+	return c
+}
+
+// matchLog finds the last log line a rule matches, the closest to the crash
+func matchLog(log string) (*logRule, string) {
+	lines := strings.Split(log, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		for j := range logRules {
+			if logRules[j].Pattern.MatchString(line) {
+				if len(line) > maxEvidenceLength {
+					line = line[:maxEvidenceLength]
+				}
+				return &logRules[j], line
+			}
+		}
+	}
+	return nil, ""
+}
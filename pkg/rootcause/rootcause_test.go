@@ -0,0 +1,123 @@
+package rootcause
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestClassifyExitCodes(t *testing.T) {
+	tests := []struct {
+		in       Input
+		category string
+		signal   string
+	}{
+		{Input{ExitCode: 137, Reason: "OOMKilled"}, CategoryOOMKilled, "SIGKILL"},
+		{Input{ExitCode: 137, Reason: "Error"}, CategoryKilled, "SIGKILL"},
+		{Input{ExitCode: 143, Reason: "Error"}, CategoryTerminated, "SIGTERM"},
+		{Input{ExitCode: 139, Reason: "Error"}, CategorySegmentationFault, "SIGSEGV"},
+		{Input{ExitCode: 134, Reason: "Error"}, CategoryAborted, "SIGABRT"},
+		{Input{ExitCode: 135, Reason: "Error"}, CategorySignal, "SIGBUS"},
+		{Input{ExitCode: 126, Reason: "Error"}, CategoryCommandNotExecutable, ""},
+		{Input{ExitCode: 127, Reason: "Error"}, CategoryCommandNotFound, ""},
+		{Input{ExitCode: 128, Reason: "StartError", Message: `exec: "/bin/false": stat /bin/false: no such file or directory`}, CategoryCommandNotFound, ""},
+		{Input{ExitCode: 3, Reason: "Error"}, CategoryApplicationError, ""},
+		{Input{ExitCode: 0, Reason: "Completed"}, CategoryCleanExit, ""},
+		{Input{Reason: "Evicted"}, CategoryEvicted, ""},
+		// The kernel OOM kill is certain, whatever the log says
+		{Input{ExitCode: 137, Reason: "OOMKilled", Log: "dial tcp 10.0.0.1:5432: connect: connection refused"}, CategoryOOMKilled, "SIGKILL"},
+		// The log tells why a container was killed
+		{Input{ExitCode: 143, Reason: "Error", Log: "dial tcp: lookup db.internal: no such host"}, CategoryDNSFailure, "SIGTERM"},
+	}
+	for _, tt := range tests {
+		got := Classify(tt.in)
+		if got.Category != tt.category || got.Signal != tt.signal {
+			t.Errorf("Classify(%d, %s) = (%s, %s), want (%s, %s)", tt.in.ExitCode, tt.in.Reason, got.Category, got.Signal, tt.category, tt.signal)
+		}
+	}
+}
+
+func TestClassifyLogRules(t *testing.T) {
+	tests := []struct {
+		log      string
+		category string
+	}{
+		{`Exception in thread "main" java.lang.OutOfMemoryError: Java heap space`, CategoryJVMOutOfMemory},
+		{`panic: environment variable DATABASE_URL is not set`, CategoryMissingEnvVar},
+		{`KeyError: 'API_TOKEN'`, CategoryMissingEnvVar},
+		{`Error: connect ECONNREFUSED 10.96.0.12:6379`, CategoryConnectionRefused},
+		{`psycopg2.OperationalError: could not translate host name "db": Temporary failure in name resolution`, CategoryDNSFailure},
+		{`open /var/lib/app/data.db: permission denied`, CategoryPermissionDenied},
+		{`dial tcp 10.0.0.7:443: i/o timeout`, CategoryConnectionTimeout},
+		{`value not set, using default`, CategoryApplicationError},
+	}
+	for _, tt := range tests {
+		got := Classify(Input{ExitCode: 1, Reason: "Error", Log: "starting\n" + tt.log + "\nshutting down"})
+		if got.Category != tt.category {
+			t.Errorf("log %q: got %s, want %s", tt.log, got.Category, tt.category)
+		}
+		if tt.category != CategoryApplicationError && got.Evidence != tt.log {
+			t.Errorf("log %q: evidence %q", tt.log, got.Evidence)
+		}
+	}
+}
+
+// scriptOutput is what the script of an example prints before it exits:
+// the strings passed to echo, print and console.log/error
+var scriptOutput = regexp.MustCompile(`(?:echo|print|console\.(?:log|error))\(?\s*f?["']([^"']*)["']`)
+
+// exampleLog simulates the log of an example scenario
+func exampleLog(t *testing.T, name string) string {
+	data, err := os.ReadFile(filepath.Join("..", "..", "example", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.Contains(line, "exit ") && !strings.Contains(line, "exit 0") {
+			break
+		}
+		if m := scriptOutput.FindStringSubmatch(line); m != nil {
+			lines = append(lines, m[1])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestClassifyExamples(t *testing.T) {
+	tests := []struct {
+		example  string
+		in       Input
+		category string
+	}{
+		{"config-missing-crash.yaml", Input{ExitCode: 1, Reason: "Error"}, CategoryApplicationError},
+		{"crashing-pod.yaml", Input{ExitCode: 1, Reason: "Error"}, CategoryApplicationError},
+		{"db-migration-fail.yaml", Input{ExitCode: 1, Reason: "Error"}, CategoryConnectionTimeout},
+		{"distroless-secret-fail.yaml", Input{ExitCode: 128, Reason: "StartError", Message: `exec: "/bin/false": stat /bin/false: no such file or directory`}, CategoryCommandNotFound},
+		{"exception-crash.yaml", Input{ExitCode: 1, Reason: "Error"}, CategoryApplicationError},
+		{"exit-zero-loop.yaml", Input{ExitCode: 0, Reason: "Completed"}, CategoryMissingEnvVar},
+		{"flaky-service.yaml", Input{ExitCode: 1, Reason: "Error"}, CategoryApplicationError},
+		{"legacy-java-oom.yaml", Input{ExitCode: 137, Reason: "Error"}, CategoryJVMOutOfMemory},
+		{"oom-crash.yaml", Input{ExitCode: 137, Reason: "OOMKilled"}, CategoryOOMKilled},
+		{"slow-dependency-app.yaml", Input{ExitCode: 1, Reason: "Error"}, CategoryConnectionRefused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.example, func(t *testing.T) {
+			tt.in.Log = exampleLog(t, tt.example)
+			got := Classify(tt.in)
+			if got.Category != tt.category {
+				t.Errorf("got %s (%q), want %s\nlog:\n%s", got.Category, got.Evidence, tt.category, tt.in.Log)
+			}
+		})
+	}
+}
+
+func TestCauseMessage(t *testing.T) {
+	c := Classify(Input{ExitCode: 1, Reason: "Error", Log: "Error: Config service unreachable (Connection Refused)"})
+	want := `Probable cause: A dependency refused the connection (ConnectionRefused): "Error: Config service unreachable (Connection Refused)"`
+	if c.Message() != want {
+		t.Errorf("got %q, want %q", c.Message(), want)
+	}
+}
@@ -0,0 +1,207 @@
+// Package rules matches crash logs against failure patterns that are
+// specific to an organization, e.g. "FATAL: password authentication failed"
+// after a database credential rotation. Rules are loaded from ConfigMap data
+// and can be replaced at any time.
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Severities of a rule
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Actions a rule can take on a crash it matches
+const (
+	// ActionHold puts the forensic pod on hold, see forensic.io/hold
+	ActionHold = "hold"
+	// ActionSkipCapture records the match on the source pod but captures nothing
+	ActionSkipCapture = "skip-capture"
+	// ActionPage raises a page: a ForensicPage event and the forensics_rule_pages_total metric
+	ActionPage = "page"
+)
+
+// maxLineLength caps the matched log line kept as evidence
+const maxLineLength = 256
+
+// Rule is a failure pattern. Exactly one of Regex and Substring is set.
+type Rule struct {
+	Name        string   `json:"name"`
+	Regex       string   `json:"regex,omitempty"`
+	Substring   string   `json:"substring,omitempty"`
+	Severity    string   `json:"severity,omitempty"` // info, warning (default) or critical
+	Category    string   `json:"category,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+	Actions     []string `json:"actions,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// HasAction reports whether the rule takes an action
+func (r *Rule) HasAction(action string) bool {
+	for _, a := range r.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matches(line string) bool {
+	if r.pattern != nil {
+		return r.pattern.MatchString(line)
+	}
+	return strings.Contains(line, r.Substring)
+}
+
+// Match is a rule that matched a log
+type Match struct {
+	Rule *Rule
+	Line string // Last log line the rule matched
+}
+
+// file is the content of one ConfigMap key
+type file struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Parse reads the rules of every key of a ConfigMap that ends in .yaml or
+// .yml, in key order. It fails on the first invalid rule.
+func Parse(data map[string]string) ([]Rule, error) {
+	var keys []string
+	for key := range data {
+		if strings.HasSuffix(key, ".yaml") || strings.HasSuffix(key, ".yml") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var rules []Rule
+	names := make(map[string]bool)
+	for _, key := range keys {
+		var f file
+		if err := yaml.UnmarshalStrict([]byte(data[key]), &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		for i := range f.Rules {
+			rule := f.Rules[i]
+			if err := rule.validate(); err != nil {
+				return nil, fmt.Errorf("%s: rule %d (%s): %w", key, i, rule.Name, err)
+			}
+			if names[rule.Name] {
+				return nil, fmt.Errorf("%s: rule %s is defined twice", key, rule.Name)
+			}
+			names[rule.Name] = true
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// validate checks a rule and compiles its pattern
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if (r.Regex == "") == (r.Substring == "") {
+		return fmt.Errorf("exactly one of regex and substring is required")
+	}
+	if r.Regex != "" {
+		pattern, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return err
+		}
+		r.pattern = pattern
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityWarning
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return fmt.Errorf("unknown severity %q", r.Severity)
+	}
+	for _, a := range r.Actions {
+		switch a {
+		case ActionHold, ActionSkipCapture, ActionPage:
+		default:
+			return fmt.Errorf("unknown action %q", a)
+		}
+	}
+	return nil
+}
+
+// Engine holds the current rules. It is safe for concurrent use, and a nil
+// Engine has no rules.
+type Engine struct {
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+// NewEngine creates an Engine without rules
+func NewEngine() *Engine {
+	return &Engine{}
+}
+
+// Load replaces the rules with those in the ConfigMap data. When the data
+// holds an invalid rule, the current rules are kept and the error returned.
+func (e *Engine) Load(data map[string]string) (int, error) {
+	rules, err := Parse(data)
+	if err != nil {
+		return 0, err
+	}
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+	return len(rules), nil
+}
+
+// Len is the number of rules loaded
+func (e *Engine) Len() int {
+	if e == nil {
+		return 0
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.rules)
+}
+
+// Match finds the rules a log matches, in rule order, each with the last
+// line it matched, which is the closest to the crash
+func (e *Engine) Match(log string) []Match {
+	if e == nil {
+		return nil
+	}
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+	if len(rules) == 0 || log == "" {
+		return nil
+	}
+
+	lines := strings.Split(log, "\n")
+	var matches []Match
+	for i := range rules {
+		for j := len(lines) - 1; j >= 0; j-- {
+			if !rules[i].matches(lines[j]) {
+				continue
+			}
+			line := strings.TrimSpace(lines[j])
+			if len(line) > maxLineLength {
+				line = line[:maxLineLength]
+			}
+			matches = append(matches, Match{Rule: &rules[i], Line: line})
+			break
+		}
+	}
+	return matches
+}
@@ -0,0 +1,82 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+const dbRules = `rules:
+- name: db-credentials-rotated
+  substring: "FATAL: password authentication failed"
+  severity: critical
+  category: CredentialsRotated
+  remediation: Restart the pod to pick up the rotated database secret
+  actions: [hold, page]
+- name: feature-flag-timeout
+  regex: 'flagd: .* deadline exceeded'
+  severity: info
+  actions: [skip-capture]
+`
+
+func TestParse(t *testing.T) {
+	rules, err := Parse(map[string]string{"db.yaml": dbRules, "README": "not rules"})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Name != "db-credentials-rotated" || rules[1].pattern == nil {
+		t.Fatalf("got %+v", rules)
+	}
+	if !rules[0].HasAction(ActionPage) || rules[0].HasAction(ActionSkipCapture) {
+		t.Errorf("got actions %v", rules[0].Actions)
+	}
+
+	invalid := []string{
+		"rules:\n- substring: x\n",                               // no name
+		"rules:\n- name: a\n",                                    // no matcher
+		"rules:\n- name: a\n  regex: x\n  substring: x\n",        // two matchers
+		"rules:\n- name: a\n  regex: '('\n",                      // bad regex
+		"rules:\n- name: a\n  substring: x\n  severity: fatal\n", // unknown severity
+		"rules:\n- name: a\n  substring: x\n  actions: [reboot]\n",
+		"rules:\n- name: a\n  substring: x\n- name: a\n  substring: y\n",
+		"rules:\n- name: a\n  substr: x\n", // unknown field
+	}
+	for _, data := range invalid {
+		if _, err := Parse(map[string]string{"rules.yaml": data}); err == nil {
+			t.Errorf("no error for %q", data)
+		}
+	}
+}
+
+func TestEngineMatch(t *testing.T) {
+	e := NewEngine()
+	if _, err := e.Load(map[string]string{"db.yaml": dbRules}); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	log := strings.Join([]string{
+		"connecting to db-0",
+		`FATAL: password authentication failed for user "orders"`,
+		"retrying",
+		`FATAL: password authentication failed for user "billing"`,
+		"exiting",
+	}, "\n")
+	matches := e.Match(log)
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+	m := matches[0]
+	if m.Rule.Name != "db-credentials-rotated" || m.Rule.Severity != SeverityCritical || !strings.Contains(m.Line, "billing") {
+		t.Errorf("got %s on %q, want the last matching line", m.Rule.Name, m.Line)
+	}
+
+	// An invalid update keeps the rules in place
+	if _, err := e.Load(map[string]string{"db.yaml": "rules:\n- name: broken\n"}); err == nil {
+		t.Fatal("no error for an invalid update")
+	}
+	if e.Len() != 2 {
+		t.Errorf("got %d rules after an invalid update, want 2", e.Len())
+	}
+
+	if _, err := e.Load(nil); err != nil || e.Match(log) != nil {
+		t.Error("rules still match after they were removed")
+	}
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+)
+
+// AzureConnectionStringEnv holds the connection string for connection-string auth.
+// It is read from the environment so that it never shows up in flags or Job args.
+const AzureConnectionStringEnv = "AZURE_STORAGE_CONNECTION_STRING"
+
+// AzureProvider implements Provider for Azure Blob Storage
+type AzureProvider struct {
+	Client    *azblob.Client
+	Container string
+}
+
+// NewAzureProvider creates a new AzureProvider. A non-empty connection string
+// takes precedence; otherwise the account URL is used with the default Azure
+// credential chain (AKS Workload Identity, managed identity, environment).
+func NewAzureProvider(ctx context.Context, accountURL string, container string, connectionString string) (*AzureProvider, error) {
+	var client *azblob.Client
+	var err error
+	if connectionString != "" {
+		client, err = azblob.NewClientFromConnectionString(connectionString, nil)
+	} else {
+		if accountURL == "" {
+			return nil, fmt.Errorf("azure account URL is required without a connection string")
+		}
+		cred, credErr := azidentity.NewDefaultAzureCredential(nil)
+		if credErr != nil {
+			return nil, credErr
+		}
+		client, err = azblob.NewClient(accountURL, cred, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &AzureProvider{
+		Client:    client,
+		Container: container,
+	}, nil
+}
+
+// Upload uploads byte data to Azure Blob Storage
+func (e *AzureProvider) Upload(ctx context.Context, key string, data []byte, meta Metadata) (string, error) {
+	if _, err := e.Client.UploadBuffer(ctx, e.Container, key, data, &azblob.UploadBufferOptions{Metadata: azureMetadata(meta), Tags: azureTags(meta)}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("azblob://%s/%s", e.Container, key), nil
+}
+
+// UploadFile uploads a file from disk
+func (e *AzureProvider) UploadFile(ctx context.Context, key string, filePath string, meta Metadata) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// Uploaded as a block blob in parallel blocks
+	if _, err := e.Client.UploadFile(ctx, e.Container, key, file, &azblob.UploadFileOptions{Metadata: azureMetadata(meta), Tags: azureTags(meta)}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("azblob://%s/%s", e.Container, key), nil
+}
+
+// UploadStream uploads a reader as a block blob, buffering one block per worker.
+// The block list is committed only after the reader is drained, so a failing
+// reader leaves no blob; its uncommitted blocks are discarded by the service.
+func (e *AzureProvider) UploadStream(ctx context.Context, key string, r io.Reader, meta Metadata) (string, error) {
+	if _, err := e.Client.UploadStream(ctx, e.Container, key, r, &azblob.UploadStreamOptions{Metadata: azureMetadata(meta), Tags: azureTags(meta)}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("azblob://%s/%s", e.Container, key), nil
+}
+
+// List lists blobs below a prefix
+func (e *AzureProvider) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	pager := e.Client.NewListBlobsFlatPager(e.Container, &container.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range page.Segment.BlobItems {
+			o := ObjectInfo{Key: *b.Name}
+			if b.Properties != nil {
+				if b.Properties.ContentLength != nil {
+					o.Size = *b.Properties.ContentLength
+				}
+				if b.Properties.LastModified != nil {
+					o.LastModified = *b.Properties.LastModified
+				}
+			}
+			objects = append(objects, o)
+		}
+	}
+	return objects, nil
+}
+
+// Get opens a blob
+func (e *AzureProvider) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := e.Client.DownloadStream(ctx, e.Container, key, nil)
+	if err != nil {
+		return nil, azureError(err)
+	}
+	return resp.Body, nil
+}
+
+// Stat returns the size, modification time and metadata of a blob
+func (e *AzureProvider) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	props, err := e.Client.ServiceClient().NewContainerClient(e.Container).NewBlobClient(key).GetProperties(ctx, nil)
+	if err != nil {
+		return ObjectInfo{}, azureError(err)
+	}
+	o := ObjectInfo{Key: key}
+	if props.ContentLength != nil {
+		o.Size = *props.ContentLength
+	}
+	if props.LastModified != nil {
+		o.LastModified = *props.LastModified
+	}
+	if len(props.Metadata) > 0 {
+		o.Metadata = make(Metadata, len(props.Metadata))
+		for k, v := range props.Metadata {
+			if v != nil {
+				// Reverses azureMetadata; the service may also change the case
+				o.Metadata[strings.ReplaceAll(strings.ToLower(k), "_", "-")] = *v
+			}
+		}
+	}
+	return o, nil
+}
+
+// Delete removes a blob and its snapshots
+func (e *AzureProvider) Delete(ctx context.Context, key string) error {
+	include := blob.DeleteSnapshotsOptionTypeInclude
+	_, err := e.Client.DeleteBlob(ctx, e.Container, key, &blob.DeleteOptions{DeleteSnapshots: &include})
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+// azureError maps missing blobs to ErrNotFound
+func azureError(err error) error {
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
+
+// azureMetadata converts metadata keys to C# identifiers, as Azure requires
+func azureMetadata(meta Metadata) map[string]*string {
+	if len(meta) == 0 {
+		return nil
+	}
+	out := make(map[string]*string, len(meta))
+	for k, v := range meta {
+		v := v
+		out[strings.ReplaceAll(k, "-", "_")] = &v
+	}
+	return out
+}
+
+func azureTags(meta Metadata) map[string]string {
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta.Tags()
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAzureProvider runs against Azurite, see docs/getting-started.md
+func TestAzureProvider(t *testing.T) {
+	conn := os.Getenv("AZURITE_CONNECTION_STRING")
+	if conn == "" {
+		t.Skip("AZURITE_CONNECTION_STRING not set")
+	}
+	ctx := context.Background()
+
+	p, err := NewAzureProvider(ctx, "", "forensics-test", conn)
+	if err != nil {
+		t.Fatalf("NewAzureProvider: %v", err)
+	}
+	if _, err := p.Client.CreateContainer(ctx, p.Container, nil); err != nil {
+		t.Logf("create container: %v (assuming it exists)", err)
+	}
+
+	url, err := p.Upload(ctx, "default/web/app.current.log", []byte("panic: boom\n"), nil)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if url != "azblob://forensics-test/default/web/app.current.log" {
+		t.Errorf("unexpected URL %s", url)
+	}
+
+	path := filepath.Join(t.TempDir(), "checkpoint.tar")
+	if err := os.WriteFile(path, []byte("checkpoint"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.UploadFile(ctx, "default/web/checkpoint.tar", path, nil); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	resp, err := p.Client.DownloadStream(ctx, p.Container, "default/web/checkpoint.tar", nil)
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.ContentLength == nil || *resp.ContentLength != int64(len("checkpoint")) {
+		t.Errorf("unexpected content length %v", resp.ContentLength)
+	}
+
+	testLifecycle(t, p)
+}
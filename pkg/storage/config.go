@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Storage backends selectable with --storage-backend
+const (
+	BackendS3         = "s3"
+	BackendGCS        = "gcs"
+	BackendAzure      = "azblob"
+	BackendFilesystem = "filesystem"
+)
+
+// Config selects and configures the storage backend. It is shared by the
+// controller and the collector subcommand so that both upload to the same place.
+type Config struct {
+	Backend   string
+	S3Bucket  string
+	S3Region  string
+	GCSBucket string
+
+	S3Endpoint  string
+	S3PathStyle bool
+	// S3CABundle is a PEM file path. In the collector Job it is mounted from
+	// the S3CABundleConfigMap ConfigMap ("ca.crt" key) of the forensic namespace.
+	S3CABundle          string
+	S3CABundleConfigMap string
+	// S3CredentialsSecret names a Secret in the forensic namespace with
+	// "accessKeyID" and "secretAccessKey" keys, passed to the collector Job.
+	// The controller itself reads AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY.
+	S3CredentialsSecret string
+	S3PartSize          int64
+	S3Concurrency       int
+	S3PartRetries       int
+	// S3ObjectLockMode (GOVERNANCE or COMPLIANCE) and S3ObjectLockRetention
+	// lock every upload, including the collector's. S3LegalHold lets the
+	// controller map the forensic.io/hold annotation to a legal hold.
+	S3ObjectLockMode      string
+	S3ObjectLockRetention time.Duration
+	S3LegalHold           bool
+
+	AzureAccountURL string
+	AzureContainer  string
+	// AzureConnectionStringSecret names a Secret in the forensic namespace whose
+	// "connectionString" key is passed to the collector Job. The controller itself
+	// reads AZURE_STORAGE_CONNECTION_STRING.
+	AzureConnectionStringSecret string
+
+	// StoragePath is the directory used by the filesystem backend. In the
+	// collector Job it is mounted from StoragePVC of the forensic namespace.
+	StoragePath string
+	StoragePVC  string
+
+	// EncryptionRecipients are the public keys every upload is encrypted to,
+	// see ParseRecipients. They are not secret and are passed to the collector
+	// Job as a flag. LoadEncryptionRecipients fills them from EncryptionRecipientsFile.
+	EncryptionRecipients     string
+	EncryptionRecipientsFile string
+}
+
+// RegisterFlags adds the storage flags to a flag set
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Backend, "storage-backend", BackendS3, "Storage backend for exported artifacts: s3, gcs, azblob or filesystem.")
+
+	// S3 Flags
+	fs.StringVar(&c.S3Bucket, "s3-bucket", "", "S3 Bucket for exporting forensic artifacts (logs).")
+	fs.StringVar(&c.S3Region, "s3-region", "us-east-1", "AWS Region for S3.")
+	fs.StringVar(&c.S3Endpoint, "s3-endpoint", "", "Endpoint URL of an S3-compatible store (MinIO, Ceph RGW). Empty uses AWS.")
+	fs.BoolVar(&c.S3PathStyle, "s3-path-style", false, "Use path-style bucket addressing, required by most S3-compatible stores.")
+	fs.StringVar(&c.S3CABundle, "s3-ca-bundle", "", "PEM file with extra CA certificates for the S3 endpoint.")
+	fs.StringVar(&c.S3CABundleConfigMap, "s3-ca-bundle-configmap", "", "ConfigMap in the forensic namespace with a 'ca.crt' key, mounted at --s3-ca-bundle in the collector job.")
+	fs.StringVar(&c.S3CredentialsSecret, "s3-credentials-secret", "", "Secret in the forensic namespace with 'accessKeyID' and 'secretAccessKey' keys, passed to the collector job.")
+	fs.Int64Var(&c.S3PartSize, "s3-part-size", DefaultS3PartSize, "Part size in bytes of multipart uploads. Smaller uploads use a single PUT.")
+	fs.IntVar(&c.S3Concurrency, "s3-upload-concurrency", DefaultS3Concurrency, "Parts uploaded in parallel.")
+	fs.IntVar(&c.S3PartRetries, "s3-part-retries", DefaultS3PartRetries, "Retries of each failed S3 request, including each part upload.")
+	fs.StringVar(&c.S3ObjectLockMode, "s3-object-lock-mode", "", "S3 Object Lock retention mode of uploads: GOVERNANCE or COMPLIANCE. Empty disables retention. The bucket must have Object Lock enabled.")
+	fs.DurationVar(&c.S3ObjectLockRetention, "s3-object-lock-retention", 0, "How long uploads are locked with --s3-object-lock-mode (e.g., 2160h).")
+	fs.BoolVar(&c.S3LegalHold, "s3-legal-hold", false, "Place an S3 legal hold on the exported artifacts of a forensic pod while it carries forensic.io/hold=true.")
+
+	// GCS Flags
+	fs.StringVar(&c.GCSBucket, "gcs-bucket", "", "GCS Bucket for exporting forensic artifacts. Uses Workload Identity / Application Default Credentials.")
+
+	// Azure Flags
+	fs.StringVar(&c.AzureContainer, "azure-container", "", "Azure Blob container for exporting forensic artifacts.")
+	fs.StringVar(&c.AzureAccountURL, "azure-account-url", "", "Azure Storage account URL (e.g. https://<account>.blob.core.windows.net) for managed identity auth. Ignored when "+AzureConnectionStringEnv+" is set.")
+	fs.StringVar(&c.AzureConnectionStringSecret, "azure-connection-string-secret", "", "Secret in the forensic namespace with a 'connectionString' key, passed to the collector job.")
+
+	// Filesystem Flags
+	fs.StringVar(&c.StoragePath, "storage-path", "", "Directory (usually a mounted PVC) for exporting forensic artifacts with --storage-backend=filesystem.")
+	fs.StringVar(&c.StoragePVC, "storage-pvc", "", "PVC in the forensic namespace mounted at --storage-path in the collector job.")
+
+	// Encryption Flags
+	fs.StringVar(&c.EncryptionRecipients, "encryption-recipients", "", "Comma-separated age or SSH public keys. Exported artifacts are encrypted to them before upload.")
+	fs.StringVar(&c.EncryptionRecipientsFile, "encryption-recipients-file", "", "File with age or SSH public keys, one per line (e.g. a mounted Secret). Added to --encryption-recipients.")
+}
+
+// LoadEncryptionRecipients merges the recipients file into EncryptionRecipients
+func (c *Config) LoadEncryptionRecipients() error {
+	if c.EncryptionRecipientsFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.EncryptionRecipientsFile)
+	if err != nil {
+		return fmt.Errorf("encryption recipients: %w", err)
+	}
+	recipients, err := ParseRecipients(string(data))
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("encryption recipients: %s has no public keys", c.EncryptionRecipientsFile)
+	}
+
+	var keys []string
+	if c.EncryptionRecipients != "" {
+		keys = append(keys, c.EncryptionRecipients)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			keys = append(keys, line)
+		}
+	}
+	c.EncryptionRecipients = strings.Join(keys, ",")
+	c.EncryptionRecipientsFile = ""
+	return nil
+}
+
+// Enabled reports whether the selected backend has somewhere to upload to
+func (c Config) Enabled() bool {
+	switch c.Backend {
+	case BackendGCS:
+		return c.GCSBucket != ""
+	case BackendAzure:
+		return c.AzureContainer != ""
+	case BackendFilesystem:
+		return c.StoragePath != ""
+	default:
+		return c.S3Bucket != ""
+	}
+}
+
+// Args returns the flags that reproduce this config, for the collector Job
+func (c Config) Args() []string {
+	args := []string{"--storage-backend=" + c.Backend}
+	if c.EncryptionRecipients != "" {
+		args = append(args, "--encryption-recipients="+c.EncryptionRecipients)
+	}
+	switch c.Backend {
+	case BackendGCS:
+		args = append(args, "--gcs-bucket="+c.GCSBucket)
+	case BackendAzure:
+		args = append(args, "--azure-container="+c.AzureContainer, "--azure-account-url="+c.AzureAccountURL)
+	case BackendFilesystem:
+		args = append(args, "--storage-path="+c.StoragePath)
+	default:
+		args = append(args, "--s3-bucket="+c.S3Bucket, "--s3-region="+c.S3Region,
+			fmt.Sprintf("--s3-part-size=%d", c.S3PartSize),
+			fmt.Sprintf("--s3-upload-concurrency=%d", c.S3Concurrency),
+			fmt.Sprintf("--s3-part-retries=%d", c.S3PartRetries))
+		if c.S3Endpoint != "" {
+			args = append(args, "--s3-endpoint="+c.S3Endpoint)
+		}
+		if c.S3PathStyle {
+			args = append(args, "--s3-path-style")
+		}
+		if c.S3ObjectLockMode != "" {
+			args = append(args, "--s3-object-lock-mode="+c.S3ObjectLockMode, "--s3-object-lock-retention="+c.S3ObjectLockRetention.String())
+		}
+		if c.S3CABundle != "" && c.S3CABundleConfigMap != "" {
+			args = append(args, "--s3-ca-bundle="+c.S3CABundle)
+		}
+	}
+	return args
+}
+
+// New creates the Provider for the selected backend. Without a bucket it
+// returns a NoOpProvider, so uploads are skipped. With encryption recipients
+// the provider is wrapped in an EncryptedProvider.
+func New(ctx context.Context, c Config) (Provider, error) {
+	p, err := newBackend(ctx, c)
+	if err != nil || c.EncryptionRecipients == "" || !c.Enabled() {
+		return p, err
+	}
+	return NewEncryptedProvider(p, c.EncryptionRecipients)
+}
+
+func newBackend(ctx context.Context, c Config) (Provider, error) {
+	if (c.S3ObjectLockMode != "" || c.S3LegalHold) && c.Backend != BackendS3 && c.Backend != "" {
+		return nil, fmt.Errorf("s3 object lock and legal hold need --storage-backend=%s", BackendS3)
+	}
+	switch c.Backend {
+	case BackendS3, "":
+		if c.S3Bucket == "" {
+			return &NoOpProvider{}, nil
+		}
+		return NewS3Provider(ctx, c.S3Bucket, c.S3Region, S3Options{
+			Endpoint:            c.S3Endpoint,
+			PathStyle:           c.S3PathStyle,
+			CABundle:            c.S3CABundle,
+			AccessKeyID:         os.Getenv(S3AccessKeyIDEnv),
+			SecretAccessKey:     os.Getenv(S3SecretAccessKeyEnv),
+			PartSize:            c.S3PartSize,
+			Concurrency:         c.S3Concurrency,
+			PartRetries:         c.S3PartRetries,
+			ObjectLockMode:      c.S3ObjectLockMode,
+			ObjectLockRetention: c.S3ObjectLockRetention,
+		})
+	case BackendGCS:
+		if c.GCSBucket == "" {
+			return &NoOpProvider{}, nil
+		}
+		return NewGCSProvider(ctx, c.GCSBucket)
+	case BackendAzure:
+		if c.AzureContainer == "" {
+			return &NoOpProvider{}, nil
+		}
+		return NewAzureProvider(ctx, c.AzureAccountURL, c.AzureContainer, os.Getenv(AzureConnectionStringEnv))
+	case BackendFilesystem:
+		if c.StoragePath == "" {
+			return &NoOpProvider{}, nil
+		}
+		return NewFilesystemProvider(c.StoragePath)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", c.Backend)
+	}
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+)
+
+// EncryptedSuffix is appended to the key of every encrypted object
+const EncryptedSuffix = ".age"
+
+// EncryptedProvider wraps a Provider and encrypts every upload with age
+// (X25519 + ChaCha20-Poly1305) to a set of recipients. Only holders of a
+// matching identity can read the objects, even if the bucket leaks. The
+// files can also be decrypted with the age CLI.
+//
+// List, Get, Stat and Delete are passed through and see the ciphertext.
+// Metadata is stored unencrypted; its sha256 is the hash of the plaintext.
+type EncryptedProvider struct {
+	Provider
+	Recipients []age.Recipient
+}
+
+// NewEncryptedProvider wraps a provider. See ParseRecipients for the format.
+func NewEncryptedProvider(p Provider, recipients string) (*EncryptedProvider, error) {
+	parsed, err := ParseRecipients(recipients)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no encryption recipients")
+	}
+	return &EncryptedProvider{Provider: p, Recipients: parsed}, nil
+}
+
+// ParseRecipients parses age (age1...) and SSH (ssh-ed25519, ssh-rsa) public
+// keys, separated by newlines or commas. Lines starting with # are ignored.
+func ParseRecipients(text string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ',' }) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r age.Recipient
+		var err error
+		if strings.HasPrefix(line, "ssh-") {
+			r, err = agessh.ParseRecipient(line)
+		} else {
+			r, err = age.ParseX25519Recipient(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", line, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// Upload encrypts byte data and uploads it as <key>.age
+func (e *EncryptedProvider) Upload(ctx context.Context, key string, data []byte, meta Metadata) (string, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, e.Recipients...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return e.Provider.Upload(ctx, key+EncryptedSuffix, buf.Bytes(), meta)
+}
+
+// UploadFile encrypts a file while streaming it
+func (e *EncryptedProvider) UploadFile(ctx context.Context, key string, filePath string, meta Metadata) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return e.UploadStream(ctx, key, file, meta)
+}
+
+// UploadStream encrypts a reader on the fly, so plaintext never touches disk
+// or storage
+func (e *EncryptedProvider) UploadStream(ctx context.Context, key string, r io.Reader, meta Metadata) (string, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		w, err := age.Encrypt(pw, e.Recipients...)
+		if err == nil {
+			_, err = io.Copy(w, r)
+			if err == nil {
+				err = w.Close()
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+
+	url, err := e.Provider.UploadStream(ctx, key+EncryptedSuffix, pr, meta)
+	// Unblocks the encrypting goroutine if the upload stopped early
+	pr.CloseWithError(io.ErrClosedPipe)
+	return url, err
+}
+
+// ParseIdentities parses age identities (AGE-SECRET-KEY-1...) or an
+// unencrypted SSH private key
+func ParseIdentities(data []byte) ([]age.Identity, error) {
+	if bytes.Contains(data, []byte("PRIVATE KEY-----")) {
+		id, err := agessh.ParseIdentity(data)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{id}, nil
+	}
+	return age.ParseIdentities(bytes.NewReader(data))
+}
+
+// Decrypt returns a reader of the plaintext of an encrypted object
+func Decrypt(r io.Reader, identities ...age.Identity) (io.Reader, error) {
+	return age.Decrypt(r, identities...)
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestEncryptedProviderRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	backend, err := NewFilesystemProvider(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	team, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewEncryptedProvider(backend, "# incident response\n"+team.Recipient().String()+"\n")
+	if err != nil {
+		t.Fatalf("NewEncryptedProvider: %v", err)
+	}
+
+	secret := []byte("DB_PASSWORD=hunter2\n")
+	url, err := p.Upload(ctx, "prod/web/app.current.log", secret, nil)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if !strings.HasSuffix(url, "/prod/web/app.current.log.age") {
+		t.Errorf("unexpected URL %s", url)
+	}
+
+	memory := bytes.Repeat([]byte("heap "), 200000)
+	if _, err := p.UploadStream(ctx, "prod/web/checkpoint.tar", bytes.NewReader(memory), nil); err != nil {
+		t.Fatalf("UploadStream: %v", err)
+	}
+
+	for key, want := range map[string][]byte{"prod/web/app.current.log.age": secret, "prod/web/checkpoint.tar.age": memory} {
+		stored, err := os.ReadFile(filepath.Join(root, key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(stored, want[:16]) {
+			t.Errorf("%s is stored in plaintext", key)
+		}
+
+		plain, err := Decrypt(bytes.NewReader(stored), team)
+		if err != nil {
+			t.Fatalf("Decrypt %s: %v", key, err)
+		}
+		got, err := io.ReadAll(plain)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s: decrypted content differs (%v)", key, err)
+		}
+
+		if _, err := Decrypt(bytes.NewReader(stored), other); err == nil {
+			t.Errorf("%s: decrypted with the wrong identity", key)
+		}
+	}
+}
+
+func TestEncryptedProviderSSHRecipient(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend, err := NewFilesystemProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewEncryptedProvider(backend, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub))))
+	if err != nil {
+		t.Fatalf("NewEncryptedProvider: %v", err)
+	}
+	if _, err := p.Upload(context.Background(), "prod/web/app.current.log", []byte("panic: boom\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	identities, err := ParseIdentities(pem.EncodeToMemory(block))
+	if err != nil {
+		t.Fatalf("ParseIdentities: %v", err)
+	}
+	r, err := p.Get(context.Background(), "prod/web/app.current.log.age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	plain, err := Decrypt(r, identities...)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if got, _ := io.ReadAll(plain); string(got) != "panic: boom\n" {
+		t.Errorf("decrypted %q", got)
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipients, err := ParseRecipients(id.Recipient().String() + ", " + id.Recipient().String() + "\n\n# comment\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipients) != 2 {
+		t.Errorf("got %d recipients, want 2", len(recipients))
+	}
+	if _, err := ParseRecipients("not-a-key"); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+	if _, err := NewEncryptedProvider(&NoOpProvider{}, "# nobody\n"); err == nil {
+		t.Error("expected an error without recipients")
+	}
+}
+
+func TestLoadEncryptionRecipients(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "recipients")
+	if err := os.WriteFile(file, []byte("# IR team\n"+id.Recipient().String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{Backend: BackendFilesystem, StoragePath: "/var/lib/forensics", EncryptionRecipientsFile: file}
+	if err := c.LoadEncryptionRecipients(); err != nil {
+		t.Fatal(err)
+	}
+	want := "--encryption-recipients=" + id.Recipient().String()
+	found := false
+	for _, arg := range c.Args() {
+		found = found || arg == want
+	}
+	if !found {
+		t.Errorf("collector args %v do not carry %s", c.Args(), want)
+	}
+}
@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FilesystemProvider implements Provider on a local directory, typically a
+// mounted PVC, for clusters without object storage. Keys map to paths below Root.
+type FilesystemProvider struct {
+	Root string
+}
+
+// NewFilesystemProvider creates a new FilesystemProvider rooted at an existing directory
+func NewFilesystemProvider(root string) (*FilesystemProvider, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("storage path %s is not a directory", abs)
+	}
+
+	return &FilesystemProvider{Root: abs}, nil
+}
+
+// Upload writes byte data below the root
+func (e *FilesystemProvider) Upload(ctx context.Context, key string, data []byte, meta Metadata) (string, error) {
+	return e.writeWithMetadata(key, bytes.NewReader(data), meta)
+}
+
+// UploadFile copies a file from disk below the root
+func (e *FilesystemProvider) UploadFile(ctx context.Context, key string, filePath string, meta Metadata) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return e.writeWithMetadata(key, file, meta)
+}
+
+// UploadStream writes a reader below the root
+func (e *FilesystemProvider) UploadStream(ctx context.Context, key string, r io.Reader, meta Metadata) (string, error) {
+	return e.writeWithMetadata(key, r, meta)
+}
+
+// writeWithMetadata stores the metadata in a hidden .<name>.metadata.json
+// next to the artifact, which List skips
+func (e *FilesystemProvider) writeWithMetadata(key string, r io.Reader, meta Metadata) (string, error) {
+	url, err := e.write(key, r)
+	if err != nil || len(meta) == 0 {
+		return url, err
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return url, err
+	}
+	if _, err := e.write(metadataKey(key), bytes.NewReader(data)); err != nil {
+		return url, err
+	}
+	return url, nil
+}
+
+func metadataKey(key string) string {
+	return path.Join(path.Dir(key), "."+path.Base(key)+".metadata.json")
+}
+
+// path resolves a key, refusing keys that escape the root
+func (e *FilesystemProvider) path(key string) (string, error) {
+	p := filepath.Join(e.Root, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, e.Root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return p, nil
+}
+
+// write stores the content in a temporary file next to the target, syncs it
+// and renames it into place, so readers never see a partial artifact.
+func (e *FilesystemProvider) write(key string, r io.Reader) (string, error) {
+	dst, err := e.path(key)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(dst)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(dst)+".tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", err
+	}
+
+	// Persist the rename itself
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return "file://" + filepath.ToSlash(dst), nil
+}
+
+// List walks the directory of the prefix and returns the matching files.
+// Temporary files of in-flight writes are skipped.
+func (e *FilesystemProvider) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	start := e.Root
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		dir, err := e.path(prefix[:i])
+		if err != nil {
+			return nil, err
+		}
+		start = dir
+	}
+
+	var objects []ObjectInfo
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(e.Root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// Get opens a file
+func (e *FilesystemProvider) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := e.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return f, err
+}
+
+// Stat returns the size, modification time and metadata of a file
+func (e *FilesystemProvider) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	p, err := e.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ObjectInfo{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	o := ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()}
+	if mp, err := e.path(metadataKey(key)); err == nil {
+		if data, err := os.ReadFile(mp); err == nil {
+			json.Unmarshal(data, &o.Metadata)
+		}
+	}
+	return o, nil
+}
+
+// Delete removes a file and the directories it leaves empty
+func (e *FilesystemProvider) Delete(ctx context.Context, key string) error {
+	p, err := e.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if mp, err := e.path(metadataKey(key)); err == nil {
+		os.Remove(mp)
+	}
+	for dir := filepath.Dir(p); dir != e.Root; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break // Not empty
+		}
+	}
+	return nil
+}
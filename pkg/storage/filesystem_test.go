@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilesystemProvider(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	p, err := NewFilesystemProvider(root)
+	if err != nil {
+		t.Fatalf("NewFilesystemProvider: %v", err)
+	}
+
+	url, err := p.Upload(ctx, "default/web/20260101-120000/app.current.log", []byte("panic: boom\n"), nil)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	want := "file://" + filepath.ToSlash(filepath.Join(root, "default/web/20260101-120000/app.current.log"))
+	if url != want {
+		t.Errorf("got URL %s, want %s", url, want)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "default/web/20260101-120000/app.current.log"))
+	if err != nil || string(data) != "panic: boom\n" {
+		t.Errorf("unexpected content %q (%v)", data, err)
+	}
+
+	// Overwrites are atomic and leave no temporary files behind
+	src := filepath.Join(t.TempDir(), "checkpoint.tar")
+	if err := os.WriteFile(src, []byte("checkpoint"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p.UploadFile(ctx, "default/web/20260101-120000/checkpoint.tar", src, nil); err != nil {
+			t.Fatalf("UploadFile: %v", err)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(root, "default/web/20260101-120000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 files, got %d", len(entries))
+	}
+}
+
+func TestFilesystemProviderLifecycle(t *testing.T) {
+	root := t.TempDir()
+	p, err := NewFilesystemProvider(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testLifecycle(t, p)
+
+	// Emptied directories are pruned, the root is kept
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected an empty root, found %d entries", len(entries))
+	}
+}
+
+func TestFilesystemProviderRejectsEscapingKeys(t *testing.T) {
+	p, err := NewFilesystemProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"../outside.log", "default/../../outside.log", ""} {
+		if _, err := p.Upload(context.Background(), key, []byte("x"), nil); err == nil {
+			t.Errorf("key %q: expected an error", key)
+		}
+	}
+}
+
+func TestFilesystemProviderMissingRoot(t *testing.T) {
+	if _, err := NewFilesystemProvider(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expected an error for a missing root")
+	}
+}
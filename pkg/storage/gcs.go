@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// GCSProvider implements Provider for Google Cloud Storage
+type GCSProvider struct {
+	Client *gcs.Client
+	Bucket string
+}
+
+// NewGCSProvider creates a new GCSProvider. Credentials come from Application
+// Default Credentials, i.e. Workload Identity on GKE. The client honours
+// STORAGE_EMULATOR_HOST, which points it at a local fake GCS server.
+func NewGCSProvider(ctx context.Context, bucket string) (*GCSProvider, error) {
+	client, err := gcs.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GCSProvider{
+		Client: client,
+		Bucket: bucket,
+	}, nil
+}
+
+// Upload uploads byte data to GCS
+func (e *GCSProvider) Upload(ctx context.Context, key string, data []byte, meta Metadata) (string, error) {
+	// Closing the writer commits whatever was written, so a failed write is
+	// aborted by cancelling its context instead
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := e.Client.Bucket(e.Bucket).Object(key).NewWriter(wctx)
+	w.Metadata = meta
+	if _, err := w.Write(data); err != nil {
+		cancel()
+		w.Close()
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("gs://%s/%s", e.Bucket, key), nil
+}
+
+// UploadFile uploads a file from disk
+func (e *GCSProvider) UploadFile(ctx context.Context, key string, filePath string, meta Metadata) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return e.UploadStream(ctx, key, file, meta)
+}
+
+// UploadStream uploads a reader. The writer uploads in resumable chunks, so
+// large files are not held in memory. If the reader fails, the resumable
+// session is cancelled and never finalized, so no truncated object appears.
+func (e *GCSProvider) UploadStream(ctx context.Context, key string, r io.Reader, meta Metadata) (string, error) {
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := e.Client.Bucket(e.Bucket).Object(key).NewWriter(wctx)
+	w.Metadata = meta
+	if _, err := io.Copy(w, r); err != nil {
+		cancel()
+		w.Close()
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("gs://%s/%s", e.Bucket, key), nil
+}
+
+// List lists objects below a prefix
+func (e *GCSProvider) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	it := e.Client.Bucket(e.Bucket).Objects(ctx, &gcs.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, ObjectInfo{Key: attrs.Name, Size: attrs.Size, LastModified: attrs.Updated})
+	}
+	return objects, nil
+}
+
+// Get opens an object
+func (e *GCSProvider) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := e.Client.Bucket(e.Bucket).Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, gcsError(err)
+	}
+	return r, nil
+}
+
+// Stat returns the size, modification time and metadata of an object
+func (e *GCSProvider) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	attrs, err := e.Client.Bucket(e.Bucket).Object(key).Attrs(ctx)
+	if err != nil {
+		return ObjectInfo{}, gcsError(err)
+	}
+	return ObjectInfo{Key: key, Size: attrs.Size, LastModified: attrs.Updated, Metadata: attrs.Metadata}, nil
+}
+
+// Delete removes an object
+func (e *GCSProvider) Delete(ctx context.Context, key string) error {
+	err := e.Client.Bucket(e.Bucket).Object(key).Delete(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+// gcsError maps missing objects to ErrNotFound
+func gcsError(err error) error {
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// TestGCSProvider runs against a local fake GCS server, e.g.
+//
+//	docker run -d -p 4443:4443 fsouza/fake-gcs-server -scheme http
+//	STORAGE_EMULATOR_HOST=localhost:4443 go test ./pkg/storage/ -run GCS
+func TestGCSProvider(t *testing.T) {
+	if os.Getenv("STORAGE_EMULATOR_HOST") == "" {
+		t.Skip("STORAGE_EMULATOR_HOST not set")
+	}
+	ctx := context.Background()
+
+	p, err := NewGCSProvider(ctx, "forensics-test")
+	if err != nil {
+		t.Fatalf("NewGCSProvider: %v", err)
+	}
+	if err := p.Client.Bucket(p.Bucket).Create(ctx, "test-project", nil); err != nil {
+		t.Logf("create bucket: %v (assuming it exists)", err)
+	}
+
+	url, err := p.Upload(ctx, "default/web/2026/01/02/100405/app.current.log", []byte("panic: boom\n"), nil)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if url != "gs://forensics-test/default/web/2026/01/02/100405/app.current.log" {
+		t.Errorf("unexpected URL %s", url)
+	}
+
+	path := filepath.Join(t.TempDir(), "checkpoint.tar")
+	if err := os.WriteFile(path, []byte("checkpoint"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.UploadFile(ctx, "default/web/checkpoint.tar", path, nil); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	r, err := p.Client.Bucket(p.Bucket).Object("default/web/checkpoint.tar").NewReader(ctx)
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	if string(data) != "checkpoint" {
+		t.Errorf("read back %q", data)
+	}
+
+	testLifecycle(t, p)
+}
+
+// TestGCSUploadStreamAbortsOnReadError checks, without an emulator, that a
+// failing reader never reaches the API: closing the writer instead of
+// cancelling it would finalize the partial content as the object.
+func TestGCSUploadStreamAbortsOnReadError(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "unexpected request", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	client, err := gcs.NewClient(ctx, option.WithEndpoint(srv.URL+"/storage/v1/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	p := &GCSProvider{Client: client, Bucket: "forensics-test"}
+
+	readErr := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader("partial checkpoint"), iotest.ErrReader(readErr))
+	if _, err := p.UploadStream(ctx, "default/web/checkpoint.tar", r, nil); !errors.Is(err, readErr) {
+		t.Fatalf("got %v, want the reader error", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("failed upload sent %d requests, want none", n)
+	}
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Metadata describes an uploaded artifact so that it can be identified in the
+// bucket after the forensic pod is gone. It is stored as object metadata and,
+// where the backend supports it, as object tags for lifecycle rules.
+type Metadata map[string]string
+
+// Metadata keys set by the controller and the collector
+const (
+	MetaNamespace         = "source-namespace"
+	MetaPod               = "source-pod"
+	MetaPodUID            = "source-pod-uid"
+	MetaContainer         = "container"
+	MetaExitCode          = "exit-code"
+	MetaCrashSignature    = "crash-signature"
+	MetaSHA256            = "sha256"
+	MetaControllerVersion = "controller-version"
+	MetaCluster           = "cluster"
+)
+
+// Object tag limits shared by S3 and Azure Blob Storage
+const (
+	maxTags        = 10
+	maxTagKeyLen   = 128
+	maxTagValueLen = 256
+)
+
+// With returns a copy with an extra entry. Empty values are left out.
+func (m Metadata) With(key, value string) Metadata {
+	out := make(Metadata, len(m)+1)
+	for k, v := range m {
+		out[k] = v
+	}
+	if value != "" {
+		out[key] = value
+	}
+	return out
+}
+
+// Tags returns the metadata restricted to the characters and sizes allowed in
+// S3 and Azure object tags. Entries beyond the tag limit are dropped in key order.
+func (m Metadata) Tags() map[string]string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tags := make(map[string]string, len(m))
+	for _, k := range keys {
+		if len(tags) == maxTags {
+			break
+		}
+		tags[truncate(tagSafe(k), maxTagKeyLen)] = truncate(tagSafe(m[k]), maxTagValueLen)
+	}
+	return tags
+}
+
+// Encode serializes the metadata for a command-line flag
+func (m Metadata) Encode() string {
+	v := url.Values{}
+	for k, val := range m {
+		v.Set(k, val)
+	}
+	return v.Encode()
+}
+
+// ParseMetadata reverses Encode
+func ParseMetadata(s string) (Metadata, error) {
+	v, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	m := make(Metadata, len(v))
+	for k := range v {
+		m[k] = v.Get(k)
+	}
+	return m, nil
+}
+
+func tagSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune(" +-./:=_", r):
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMetadataTags(t *testing.T) {
+	m := Metadata{
+		MetaPod:            "web-7d9f",
+		MetaCrashSignature: "panic: boom! (#1)",
+		MetaSHA256:         strings.Repeat("a", 300),
+	}
+	tags := m.Tags()
+	if tags[MetaPod] != "web-7d9f" {
+		t.Errorf("pod tag = %q", tags[MetaPod])
+	}
+	if got := tags[MetaCrashSignature]; got != "panic: boom_ __1_" {
+		t.Errorf("signature tag not sanitized: %q", got)
+	}
+	if got := len(tags[MetaSHA256]); got != maxTagValueLen {
+		t.Errorf("long tag value has %d bytes, want %d", got, maxTagValueLen)
+	}
+
+	many := Metadata{}
+	for i := 0; i < 15; i++ {
+		many[fmt.Sprintf("key-%02d", i)] = "v"
+	}
+	tags = many.Tags()
+	if len(tags) != maxTags {
+		t.Fatalf("got %d tags, want %d", len(tags), maxTags)
+	}
+	if _, ok := tags["key-10"]; ok {
+		t.Error("tags beyond the limit should be dropped in key order")
+	}
+}
+
+func TestMetadataWith(t *testing.T) {
+	m := Metadata{MetaPod: "web"}
+	n := m.With(MetaContainer, "app").With(MetaExitCode, "")
+	if len(m) != 1 {
+		t.Errorf("With modified the receiver: %v", m)
+	}
+	if n[MetaContainer] != "app" {
+		t.Errorf("container = %q", n[MetaContainer])
+	}
+	if _, ok := n[MetaExitCode]; ok {
+		t.Error("empty values should be left out")
+	}
+}
+
+func TestMetadataEncode(t *testing.T) {
+	m := Metadata{MetaPod: "web", MetaCrashSignature: "exit 137 & OOM=yes"}
+	got, err := ParseMetadata(m.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(m) || got[MetaPod] != "web" || got[MetaCrashSignature] != m[MetaCrashSignature] {
+		t.Errorf("round trip = %v, want %v", got, m)
+	}
+
+	empty, err := ParseMetadata("")
+	if err != nil || len(empty) != 0 {
+		t.Errorf("ParseMetadata(\"\") = %v, %v", empty, err)
+	}
+}
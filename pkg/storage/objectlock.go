@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// S3 Object Lock retention modes accepted by --s3-object-lock-mode
+const (
+	ObjectLockGovernance = "GOVERNANCE"
+	ObjectLockCompliance = "COMPLIANCE"
+)
+
+// ErrObjectLockDisabled is returned when the bucket has no Object Lock
+// configuration, which can only be enabled when the bucket is created
+var ErrObjectLockDisabled = errors.New("object lock is not enabled on the bucket")
+
+// ObjectLocker is implemented by providers that support WORM retention
+type ObjectLocker interface {
+	// CheckObjectLock returns ErrObjectLockDisabled if the bucket cannot hold locked objects
+	CheckObjectLock(ctx context.Context) error
+	// SetLegalHold places or releases a legal hold on an object
+	SetLegalHold(ctx context.Context, key string, on bool) error
+}
+
+// AsObjectLocker returns the ObjectLocker behind a provider, looking through encryption
+func AsObjectLocker(p Provider) (ObjectLocker, bool) {
+	if e, ok := p.(*EncryptedProvider); ok {
+		p = e.Provider
+	}
+	l, ok := p.(ObjectLocker)
+	return l, ok
+}
+
+// validateObjectLock checks the retention settings and normalizes the mode
+func validateObjectLock(mode string, retention time.Duration) (types.ObjectLockMode, error) {
+	switch m := strings.ToUpper(mode); m {
+	case "":
+		return "", nil
+	case ObjectLockGovernance, ObjectLockCompliance:
+		if retention <= 0 {
+			return "", fmt.Errorf("s3 object lock mode %s needs a positive retention", m)
+		}
+		return types.ObjectLockMode(m), nil
+	default:
+		return "", fmt.Errorf("invalid s3 object lock mode %q, want %s or %s", mode, ObjectLockGovernance, ObjectLockCompliance)
+	}
+}
+
+// CheckObjectLock reads the Object Lock configuration of the bucket
+func (e *S3Provider) CheckObjectLock(ctx context.Context) error {
+	out, err := e.Client.GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(e.Bucket),
+	})
+	if err != nil {
+		return objectLockError(err)
+	}
+	if out.ObjectLockConfiguration == nil || out.ObjectLockConfiguration.ObjectLockEnabled != types.ObjectLockEnabledEnabled {
+		return fmt.Errorf("%w: %s", ErrObjectLockDisabled, e.Bucket)
+	}
+	return nil
+}
+
+// SetLegalHold places or releases a legal hold on an object. A held object
+// cannot be deleted or overwritten, whatever its retention.
+func (e *S3Provider) SetLegalHold(ctx context.Context, key string, on bool) error {
+	status := types.ObjectLockLegalHoldStatusOff
+	if on {
+		status = types.ObjectLockLegalHoldStatusOn
+	}
+	_, err := e.Client.PutObjectLegalHold(ctx, &s3.PutObjectLegalHoldInput{
+		Bucket:    aws.String(e.Bucket),
+		Key:       aws.String(key),
+		LegalHold: &types.ObjectLockLegalHold{Status: status},
+	})
+	return objectLockError(err)
+}
+
+// objectLockError maps the errors S3 returns for a bucket without Object Lock
+// to ErrObjectLockDisabled
+func objectLockError(err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch {
+	case apiErr.ErrorCode() == "ObjectLockConfigurationNotFoundError",
+		apiErr.ErrorCode() == "InvalidRequest" && strings.Contains(apiErr.ErrorMessage(), "Object Lock"):
+		return fmt.Errorf("%w: %v", ErrObjectLockDisabled, err)
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeObjectLockS3 answers the few S3 calls used with Object Lock
+type fakeObjectLockS3 struct {
+	mu        sync.Mutex
+	enabled   bool
+	headers   http.Header // Of the last PutObject
+	legalHold string      // Body of the last PutObjectLegalHold
+}
+
+func (f *fakeObjectLockS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+
+	if !f.enabled {
+		code, status := "InvalidRequest", http.StatusBadRequest
+		if r.Method == http.MethodGet {
+			code, status = "ObjectLockConfigurationNotFoundError", http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, "<Error><Code>"+code+"</Code><Message>Bucket is missing Object Lock Configuration</Message></Error>")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Has("object-lock"):
+		io.WriteString(w, "<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>")
+	case r.Method == http.MethodPut && r.URL.Query().Has("legal-hold"):
+		f.legalHold = string(body)
+	case r.Method == http.MethodPut:
+		f.headers = r.Header.Clone()
+		w.Header().Set("ETag", `"etag"`)
+	default:
+		http.Error(w, "unexpected request", http.StatusNotImplemented)
+	}
+}
+
+func newObjectLockProvider(t *testing.T, f *fakeObjectLockS3, mode string) *S3Provider {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	p, err := NewS3Provider(context.Background(), "evidence", "us-east-1", S3Options{
+		Endpoint:            srv.URL,
+		PathStyle:           true,
+		AccessKeyID:         "test",
+		SecretAccessKey:     "test",
+		PartRetries:         1,
+		ObjectLockMode:      mode,
+		ObjectLockRetention: 90 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Provider: %v", err)
+	}
+	return p
+}
+
+func TestS3ObjectLockRetention(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeObjectLockS3{enabled: true}
+	p := newObjectLockProvider(t, f, "compliance")
+
+	if err := p.CheckObjectLock(ctx); err != nil {
+		t.Fatalf("CheckObjectLock: %v", err)
+	}
+	if _, err := p.Upload(ctx, "prod/web/app.current.log", []byte("panic: boom\n"), nil); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if got := f.headers.Get("X-Amz-Object-Lock-Mode"); got != ObjectLockCompliance {
+		t.Errorf("object lock mode = %q, want %s", got, ObjectLockCompliance)
+	}
+	until, err := time.Parse(time.RFC3339, f.headers.Get("X-Amz-Object-Lock-Retain-Until-Date"))
+	if err != nil {
+		t.Fatalf("retain until date: %v", err)
+	}
+	if d := time.Until(until); d < 89*24*time.Hour || d > 91*24*time.Hour {
+		t.Errorf("retained for %s, want 90 days", d)
+	}
+
+	if err := p.SetLegalHold(ctx, "prod/web/app.current.log", true); err != nil {
+		t.Fatalf("SetLegalHold: %v", err)
+	}
+	if !strings.Contains(f.legalHold, "<Status>ON</Status>") {
+		t.Errorf("legal hold request %q, want status ON", f.legalHold)
+	}
+}
+
+func TestS3ObjectLockDisabledBucket(t *testing.T) {
+	ctx := context.Background()
+	p := newObjectLockProvider(t, &fakeObjectLockS3{}, ObjectLockGovernance)
+
+	if err := p.CheckObjectLock(ctx); !errors.Is(err, ErrObjectLockDisabled) {
+		t.Errorf("CheckObjectLock: got %v, want ErrObjectLockDisabled", err)
+	}
+	if _, err := p.Upload(ctx, "prod/web/app.current.log", []byte("x"), nil); !errors.Is(err, ErrObjectLockDisabled) {
+		t.Errorf("Upload: got %v, want ErrObjectLockDisabled", err)
+	}
+	if err := p.SetLegalHold(ctx, "prod/web/app.current.log", true); !errors.Is(err, ErrObjectLockDisabled) {
+		t.Errorf("SetLegalHold: got %v, want ErrObjectLockDisabled", err)
+	}
+}
+
+func TestValidateObjectLock(t *testing.T) {
+	tests := []struct {
+		mode      string
+		retention time.Duration
+		wantErr   bool
+	}{
+		{"", 0, false},
+		{"governance", time.Hour, false},
+		{"COMPLIANCE", 2160 * time.Hour, false},
+		{"COMPLIANCE", 0, true},
+		{"worm", time.Hour, true},
+	}
+	for _, tt := range tests {
+		if _, err := validateObjectLock(tt.mode, tt.retention); (err != nil) != tt.wantErr {
+			t.Errorf("validateObjectLock(%q, %s) = %v, wantErr %v", tt.mode, tt.retention, err, tt.wantErr)
+		}
+	}
+}
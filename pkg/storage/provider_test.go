@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// testLifecycle exercises List, Get, Stat and Delete on any provider
+func testLifecycle(t *testing.T, p Provider) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, key := range []string{"lifecycle/web/a.log", "lifecycle/web/b.log", "lifecycle/api/c.log"} {
+		if _, err := p.Upload(ctx, key, []byte(key), nil); err != nil {
+			t.Fatalf("Upload %s: %v", key, err)
+		}
+	}
+
+	objects, err := p.List(ctx, "lifecycle/web/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("List returned %d objects, want 2: %+v", len(objects), objects)
+	}
+
+	info, err := p.Stat(ctx, "lifecycle/web/a.log")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size != int64(len("lifecycle/web/a.log")) || info.LastModified.IsZero() {
+		t.Errorf("unexpected stat %+v", info)
+	}
+
+	r, err := p.Get(ctx, "lifecycle/web/b.log")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != "lifecycle/web/b.log" {
+		t.Errorf("Get returned %q (%v)", data, err)
+	}
+
+	for _, o := range objects {
+		if err := p.Delete(ctx, o.Key); err != nil {
+			t.Fatalf("Delete %s: %v", o.Key, err)
+		}
+	}
+	if err := p.Delete(ctx, "lifecycle/web/a.log"); err != nil {
+		t.Errorf("Delete of a missing object: %v", err)
+	}
+	if _, err := p.Stat(ctx, "lifecycle/web/a.log"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after Delete: got %v, want ErrNotFound", err)
+	}
+	if _, err := p.Get(ctx, "lifecycle/web/a.log"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+
+	meta := Metadata{MetaPodUID: "uid-1", MetaControllerVersion: "v1"}
+	if _, err := p.Upload(ctx, "lifecycle/meta.log", []byte("x"), meta); err != nil {
+		t.Fatalf("Upload with metadata: %v", err)
+	}
+	info, err = p.Stat(ctx, "lifecycle/meta.log")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Metadata[MetaPodUID] != "uid-1" || info.Metadata[MetaControllerVersion] != "v1" {
+		t.Errorf("Stat returned metadata %v, want %v", info.Metadata, meta)
+	}
+	p.Delete(ctx, "lifecycle/meta.log")
+
+	objects, err = p.List(ctx, "lifecycle/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "lifecycle/api/c.log" {
+		t.Errorf("unexpected objects after Delete: %+v", objects)
+	}
+	p.Delete(ctx, "lifecycle/api/c.log")
+
+	testFailedStream(t, p)
+}
+
+// testFailedStream checks that a reader failing mid-stream leaves no object
+// behind, not even a truncated one
+func testFailedStream(t *testing.T, p Provider) {
+	t.Helper()
+	ctx := context.Background()
+
+	readErr := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader("partial checkpoint"), iotest.ErrReader(readErr))
+	if _, err := p.UploadStream(ctx, "lifecycle/failed/checkpoint.tar", r, Metadata{"source-pod-uid": "uid-1"}); err == nil {
+		t.Fatal("UploadStream of a failing reader succeeded")
+	}
+	if _, err := p.Stat(ctx, "lifecycle/failed/checkpoint.tar"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after a failed upload: got %v, want ErrNotFound", err)
+	}
+	objects, err := p.List(ctx, "lifecycle/failed/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("failed upload left objects behind: %+v", objects)
+	}
+	p.Delete(ctx, "lifecycle/failed/checkpoint.tar")
+}
@@ -3,80 +3,336 @@ package storage
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 )
 
-// Provider defines the interface for uploading forensic artifacts
+// Static S3 credentials are read from the standard AWS variables, which the
+// Helm chart and the collector Job fill from a Secret.
+const (
+	S3AccessKeyIDEnv     = "AWS_ACCESS_KEY_ID"
+	S3SecretAccessKeyEnv = "AWS_SECRET_ACCESS_KEY"
+)
+
+// Provider defines the interface for storing forensic artifacts. Keys are
+// relative to the bucket, container or root directory of the provider.
 type Provider interface {
-	Upload(ctx context.Context, key string, data []byte) (string, error)
-	UploadFile(ctx context.Context, key string, filePath string) (string, error)
+	Upload(ctx context.Context, key string, data []byte, meta Metadata) (string, error)
+	UploadFile(ctx context.Context, key string, filePath string, meta Metadata) (string, error)
+	// UploadStream uploads a reader of unknown length, read exactly once in order
+	UploadStream(ctx context.Context, key string, r io.Reader, meta Metadata) (string, error)
+	// List returns every object whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Get opens an object for reading. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
 }
 
-// S3Provider implements Provider for AWS S3
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	Metadata     Metadata // Only set by Stat
+}
+
+// ErrNotFound is returned by Get and Stat for missing objects
+var ErrNotFound = errors.New("object not found")
+
+// S3Provider implements Provider for AWS S3 and S3-compatible stores
 type S3Provider struct {
-	Client *s3.Client
-	Bucket string
-	Region string
+	Client   *s3.Client
+	Bucket   string
+	Region   string
+	Endpoint string
+	// ObjectLockMode and ObjectLockRetention put every upload under Object Lock
+	// retention until upload time + ObjectLockRetention. Empty mode disables it.
+	ObjectLockMode      types.ObjectLockMode
+	ObjectLockRetention time.Duration
+
+	uploader *manager.Uploader
+}
+
+// Multipart upload defaults, sized for multi-GB checkpoints on slow links
+const (
+	DefaultS3PartSize    = 64 * 1024 * 1024
+	DefaultS3Concurrency = 4
+	DefaultS3PartRetries = 5
+)
+
+// S3Options point the provider at an S3-compatible store such as MinIO or Ceph RGW
+type S3Options struct {
+	// Endpoint replaces the AWS endpoint, e.g. https://minio.storage:9000
+	Endpoint string
+	// PathStyle addresses buckets as <endpoint>/<bucket> instead of <bucket>.<endpoint>
+	PathStyle bool
+	// CABundle is a PEM file trusted in addition to the system roots
+	CABundle string
+	// AccessKeyID and SecretAccessKey replace the default credential chain when set
+	AccessKeyID     string
+	SecretAccessKey string
+	// PartSize, Concurrency and PartRetries tune multipart uploads. Uploads
+	// smaller than PartSize are sent in a single PUT. Zero uses the defaults.
+	PartSize    int64
+	Concurrency int
+	PartRetries int
+	// ObjectLockMode (GOVERNANCE or COMPLIANCE) and ObjectLockRetention lock
+	// every upload. The bucket must have Object Lock enabled.
+	ObjectLockMode      string
+	ObjectLockRetention time.Duration
 }
 
 // NewS3Provider creates a new S3Provider
-func NewS3Provider(ctx context.Context, bucket string, region string) (*S3Provider, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+func NewS3Provider(ctx context.Context, bucket string, region string, opts S3Options) (*S3Provider, error) {
+	lockMode, err := validateObjectLock(opts.ObjectLockMode, opts.ObjectLockRetention)
 	if err != nil {
 		return nil, err
 	}
 
+	loadOpts := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	if opts.CABundle != "" {
+		ca, err := os.Open(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("s3 CA bundle: %w", err)
+		}
+		defer ca.Close()
+		loadOpts = append(loadOpts, config.WithCustomCABundle(ca))
+	}
+	if opts.AccessKeyID != "" {
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(opts.AccessKeyID, opts.SecretAccessKey, "")))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(opts.Endpoint, "/")
+	retries := opts.PartRetries
+	if retries <= 0 {
+		retries = DefaultS3PartRetries
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+		o.UsePathStyle = opts.PathStyle
+		// Every request, including each part upload, is retried on its own
+		o.RetryMaxAttempts = retries + 1
+	})
+
+	uploader := manager.NewUploader(client, func(u *manager.Uploader) {
+		u.PartSize = DefaultS3PartSize
+		if opts.PartSize > 0 {
+			u.PartSize = opts.PartSize
+		}
+		u.Concurrency = DefaultS3Concurrency
+		if opts.Concurrency > 0 {
+			u.Concurrency = opts.Concurrency
+		}
+		// Failed uploads are aborted by upload, even after ctx is cancelled
+		u.LeavePartsOnError = true
+	})
+
 	return &S3Provider{
-		Client: s3.NewFromConfig(cfg),
-		Bucket: bucket,
-		Region: region,
+		Client:              client,
+		Bucket:              bucket,
+		Region:              region,
+		Endpoint:            endpoint,
+		ObjectLockMode:      lockMode,
+		ObjectLockRetention: opts.ObjectLockRetention,
+		uploader:            uploader,
 	}, nil
 }
 
+// objectURL returns the location of an object. With a custom endpoint the URL
+// is path-style on that endpoint, so it can be resolved without knowing the config.
+func (e *S3Provider) objectURL(key string) string {
+	if e.Endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", e.Endpoint, e.Bucket, key)
+	}
+	return fmt.Sprintf("s3://%s/%s", e.Bucket, key)
+}
+
 // Upload uploads byte data to S3
-func (e *S3Provider) Upload(ctx context.Context, key string, data []byte) (string, error) {
-	_, err := e.Client.PutObject(ctx, &s3.PutObjectInput{
+func (e *S3Provider) Upload(ctx context.Context, key string, data []byte, meta Metadata) (string, error) {
+	return e.upload(ctx, key, bytes.NewReader(data), meta)
+}
+
+// UploadFile uploads a file from disk. The file is seekable, so its parts
+// are read and sent in parallel.
+func (e *S3Provider) UploadFile(ctx context.Context, key string, filePath string, meta Metadata) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return e.upload(ctx, key, file, meta)
+}
+
+// UploadStream uploads a reader. Parts are buffered in memory
+// (PartSize x Concurrency) so that each one can be retried.
+func (e *S3Provider) UploadStream(ctx context.Context, key string, r io.Reader, meta Metadata) (string, error) {
+	// Hide any Seek method so the reader is consumed once, in order
+	return e.upload(ctx, key, struct{ io.Reader }{r}, meta)
+}
+
+// upload sends a body as a single PUT or a multipart upload. A failed
+// multipart upload is aborted so no orphaned parts are billed. Metadata is
+// stored as x-amz-meta-* headers and as object tags.
+func (e *S3Provider) upload(ctx context.Context, key string, body io.Reader, meta Metadata) (string, error) {
+	input := &s3.PutObjectInput{
 		Bucket: aws.String(e.Bucket),
 		Key:    aws.String(key),
-		Body:   bytes.NewReader(data),
-	})
+		Body:   body,
+	}
+	if e.ObjectLockMode != "" {
+		input.ObjectLockMode = e.ObjectLockMode
+		input.ObjectLockRetainUntilDate = aws.Time(time.Now().Add(e.ObjectLockRetention))
+	}
+	if len(meta) > 0 {
+		input.Metadata = meta
+		tags := url.Values{}
+		for k, v := range meta.Tags() {
+			tags.Set(k, v)
+		}
+		input.Tagging = aws.String(tags.Encode())
+	}
+	_, err := e.uploader.Upload(ctx, input)
 	if err != nil {
-		return "", err
+		var multi manager.MultiUploadFailure
+		if errors.As(err, &multi) {
+			// The upload context may be cancelled already
+			abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+			defer cancel()
+			_, abortErr := e.Client.AbortMultipartUpload(abortCtx, &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(e.Bucket),
+				Key:      aws.String(key),
+				UploadId: aws.String(multi.UploadID()),
+			})
+			if abortErr != nil {
+				return "", fmt.Errorf("%w (aborting upload %s failed: %v)", err, multi.UploadID(), abortErr)
+			}
+		}
+		return "", objectLockError(err)
 	}
-	return fmt.Sprintf("s3://%s/%s", e.Bucket, key), nil
+	return e.objectURL(key), nil
 }
 
-// UploadFile uploads a file from disk
-func (e *S3Provider) UploadFile(ctx context.Context, key string, filePath string) (string, error) {
-	file, err := os.Open(filePath)
+// List lists objects below a prefix
+func (e *S3Provider) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	pages := s3.NewListObjectsV2Paginator(e.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(e.Bucket),
+		Prefix: aws.String(prefix),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.ToString(o.Key),
+				Size:         aws.ToInt64(o.Size),
+				LastModified: aws.ToTime(o.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// Get opens an object
+func (e *S3Provider) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := e.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(e.Bucket),
+		Key:    aws.String(key),
+	})
 	if err != nil {
-		return "", err
+		return nil, s3Error(err)
 	}
-	defer file.Close()
+	return out.Body, nil
+}
 
-	_, err = e.Client.PutObject(ctx, &s3.PutObjectInput{
+// Stat returns the size, modification time and metadata of an object
+func (e *S3Provider) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	out, err := e.Client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(e.Bucket),
 		Key:    aws.String(key),
-		Body:   file,
 	})
 	if err != nil {
-		return "", err
+		return ObjectInfo{}, s3Error(err)
 	}
-	return fmt.Sprintf("s3://%s/%s", e.Bucket, key), nil
+	return ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+// Delete removes an object
+func (e *S3Provider) Delete(ctx context.Context, key string) error {
+	_, err := e.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(e.Bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// s3Error maps missing keys to ErrNotFound
+func s3Error(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NoSuchKey" || apiErr.ErrorCode() == "NotFound") {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
 }
 
 // NoOpProvider is a fallback
 type NoOpProvider struct{}
 
-func (e *NoOpProvider) Upload(ctx context.Context, key string, data []byte) (string, error) {
+func (e *NoOpProvider) Upload(ctx context.Context, key string, data []byte, meta Metadata) (string, error) {
+	return "", nil
+}
+
+func (e *NoOpProvider) UploadFile(ctx context.Context, key string, filePath string, meta Metadata) (string, error) {
 	return "", nil
 }
 
-func (e *NoOpProvider) UploadFile(ctx context.Context, key string, filePath string) (string, error) {
+func (e *NoOpProvider) UploadStream(ctx context.Context, key string, r io.Reader, meta Metadata) (string, error) {
 	return "", nil
 }
+
+func (e *NoOpProvider) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	return nil, nil
+}
+
+func (e *NoOpProvider) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return nil, ErrNotFound
+}
+
+func (e *NoOpProvider) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	return ObjectInfo{}, ErrNotFound
+}
+
+func (e *NoOpProvider) Delete(ctx context.Context, key string) error {
+	return nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestS3URL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"", "s3://evidence/default/web/app.current.log"},
+		{"https://minio.storage:9000", "https://minio.storage:9000/evidence/default/web/app.current.log"},
+		{"https://rgw.example.com/", "https://rgw.example.com/evidence/default/web/app.current.log"},
+	}
+	for _, tt := range tests {
+		p, err := NewS3Provider(context.Background(), "evidence", "us-east-1", S3Options{
+			Endpoint:        tt.endpoint,
+			PathStyle:       true,
+			AccessKeyID:     "test",
+			SecretAccessKey: "test",
+		})
+		if err != nil {
+			t.Fatalf("NewS3Provider(%q): %v", tt.endpoint, err)
+		}
+		if got := p.objectURL("default/web/app.current.log"); got != tt.want {
+			t.Errorf("endpoint %q: got %s, want %s", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestS3CABundleMissing(t *testing.T) {
+	_, err := NewS3Provider(context.Background(), "evidence", "us-east-1", S3Options{
+		CABundle: filepath.Join(t.TempDir(), "missing.crt"),
+	})
+	if err == nil {
+		t.Fatal("expected an error for a missing CA bundle")
+	}
+}
+
+// TestS3Provider runs against MinIO, see docs/getting-started.md
+func TestS3Provider(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT not set")
+	}
+	ctx := context.Background()
+
+	p, err := NewS3Provider(ctx, "forensics-test", "us-east-1", S3Options{
+		Endpoint:        endpoint,
+		PathStyle:       true,
+		AccessKeyID:     os.Getenv(S3AccessKeyIDEnv),
+		SecretAccessKey: os.Getenv(S3SecretAccessKeyEnv),
+	})
+	if err != nil {
+		t.Fatalf("NewS3Provider: %v", err)
+	}
+	if _, err := p.Client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(p.Bucket)}); err != nil {
+		t.Logf("create bucket: %v (assuming it exists)", err)
+	}
+
+	url, err := p.Upload(ctx, "default/web/app.current.log", []byte("panic: boom\n"), nil)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if url != endpoint+"/forensics-test/default/web/app.current.log" {
+		t.Errorf("unexpected URL %s", url)
+	}
+
+	path := filepath.Join(t.TempDir(), "checkpoint.tar")
+	if err := os.WriteFile(path, []byte("checkpoint"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.UploadFile(ctx, "default/web/checkpoint.tar", path, nil); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	head, err := p.Client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(p.Bucket), Key: aws.String("default/web/checkpoint.tar")})
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if aws.ToInt64(head.ContentLength) != int64(len("checkpoint")) {
+		t.Errorf("unexpected content length %d", aws.ToInt64(head.ContentLength))
+	}
+
+	testLifecycle(t, p)
+
+	// A cancelled multipart upload is aborted, leaving no parts behind
+	mp, err := NewS3Provider(ctx, "forensics-test", "us-east-1", S3Options{
+		Endpoint:        endpoint,
+		PathStyle:       true,
+		AccessKeyID:     os.Getenv(S3AccessKeyIDEnv),
+		SecretAccessKey: os.Getenv(S3SecretAccessKeyEnv),
+		PartSize:        5 * 1024 * 1024,
+		Concurrency:     1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelCtx, cancel := context.WithCancel(ctx)
+	body := io.MultiReader(bytes.NewReader(make([]byte, 6*1024*1024)), readerFunc(func(b []byte) (int, error) {
+		cancel()
+		return 0, cancelCtx.Err()
+	}))
+	if _, err := mp.UploadStream(cancelCtx, "default/web/cancelled.tar", body, nil); err == nil {
+		t.Fatal("expected the cancelled upload to fail")
+	}
+	uploads, err := p.Client.ListMultipartUploads(ctx, &s3.ListMultipartUploadsInput{Bucket: aws.String(p.Bucket)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads.Uploads) != 0 {
+		t.Errorf("expected no pending multipart uploads, got %d", len(uploads.Uploads))
+	}
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(b []byte) (int, error) { return f(b) }
@@ -0,0 +1,7 @@
+// Package version holds the build version of the controller.
+package version
+
+// Version is set at build time:
+//
+//	go build -ldflags "-X kube-forensics-controller/pkg/version.Version=v0.5.0"
+var Version = "dev"